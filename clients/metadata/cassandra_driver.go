@@ -0,0 +1,39 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metadata
+
+import (
+	"github.com/uber/cherami-server/common/configure"
+	m "github.com/uber/cherami-thrift/.generated/go/metadata"
+)
+
+func init() {
+	RegisterDriver("cassandra", cassandraDriver{})
+}
+
+// cassandraDriver adapts the pre-existing CQL-backed implementation to
+// the Driver interface, registered as "cassandra". It is the only Driver
+// this package registers; the etcd and Consul kvStores have no Driver.
+type cassandraDriver struct{}
+
+func (cassandraDriver) New(cfg *configure.MetadataConfig) (m.TChanMetadataService, error) {
+	return NewCassandraMetadataService(cfg)
+}
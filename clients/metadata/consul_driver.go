@@ -0,0 +1,80 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metadata
+
+import (
+	"context"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// consulKV is a kvStore backed by Consul's KV store, using the
+// ModifyIndex Consul maintains per key as the CAS version and the
+// check-and-set ("cas") parameter on writes to make them conditional.
+type consulKV struct {
+	kv *consulapi.KV
+}
+
+func (k *consulKV) Get(ctx context.Context, key string) ([]byte, int64, bool, error) {
+	pair, _, err := k.kv.Get(key, (&consulapi.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if pair == nil {
+		return nil, 0, false, nil
+	}
+	return pair.Value, int64(pair.ModifyIndex), true, nil
+}
+
+func (k *consulKV) Put(ctx context.Context, key string, value []byte) error {
+	pair := &consulapi.KVPair{Key: key, Value: value}
+	_, err := k.kv.Put(pair, (&consulapi.WriteOptions{}).WithContext(ctx))
+	return err
+}
+
+func (k *consulKV) CAS(ctx context.Context, key string, value []byte, expectedVersion int64) error {
+	pair := &consulapi.KVPair{Key: key, Value: value, ModifyIndex: uint64(expectedVersion)}
+	ok, _, err := k.kv.CAS(pair, (&consulapi.WriteOptions{}).WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errCASConflict
+	}
+	return nil
+}
+
+func (k *consulKV) List(ctx context.Context, prefix string) (map[string][]byte, error) {
+	pairs, _, err := k.kv.List(prefix, (&consulapi.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string][]byte, len(pairs))
+	for _, pair := range pairs {
+		result[pair.Key] = pair.Value
+	}
+	return result, nil
+}
+
+func (k *consulKV) Delete(ctx context.Context, key string) error {
+	_, err := k.kv.Delete(key, (&consulapi.WriteOptions{}).WithContext(ctx))
+	return err
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metadata
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/uber/cherami-server/common/configure"
+	m "github.com/uber/cherami-thrift/.generated/go/metadata"
+)
+
+// Driver constructs a metadata service instance backed by a particular
+// storage technology (Cassandra, etcd, Consul, ...). Drivers register
+// themselves under a name from an init() function via RegisterDriver,
+// the same way database/sql drivers do, so that StartInputHostService,
+// StartControllerService and friends never need to know which backend
+// is actually in use.
+type Driver interface {
+	// New returns a metadata service instance configured from cfg.
+	New(cfg *configure.MetadataConfig) (m.TChanMetadataService, error)
+}
+
+var (
+	driversMu sync.RWMutex
+	drivers   = make(map[string]Driver)
+)
+
+// RegisterDriver makes a metadata driver available under name. It is
+// meant to be called from the init() function of the package that
+// implements the driver. It panics if driver is nil or if RegisterDriver
+// is called twice for the same name.
+func RegisterDriver(name string, driver Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if driver == nil {
+		panic("metadata: RegisterDriver called with nil driver")
+	}
+	if _, dup := drivers[name]; dup {
+		panic("metadata: RegisterDriver called twice for driver " + name)
+	}
+	drivers[name] = driver
+}
+
+// NewMetadataService instantiates the metadata driver named by
+// cfg.GetDriver() (defaulting to the Cassandra-backed implementation)
+// and returns the resulting metadata service. This replaces direct
+// calls to NewCassandraMetadataService so that the backend can be
+// switched via config alone.
+func NewMetadataService(cfg *configure.MetadataConfig) (m.TChanMetadataService, error) {
+	name := cfg.GetDriver()
+
+	driversMu.RLock()
+	driver, ok := drivers[name]
+	driversMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("metadata: unknown driver %q (forgot to import it?)", name)
+	}
+	return driver.New(cfg)
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metadata
+
+import (
+	"context"
+
+	"github.com/coreos/etcd/clientv3"
+)
+
+// etcdKV is a kvStore backed by etcd v3, using its built-in MVCC
+// revision as the CAS version and a transaction (STM-free Txn) to make
+// writes conditional on that revision.
+type etcdKV struct {
+	client *clientv3.Client
+}
+
+func (k *etcdKV) Get(ctx context.Context, key string) ([]byte, int64, bool, error) {
+	resp, err := k.client.Get(ctx, key)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, 0, false, nil
+	}
+	kv := resp.Kvs[0]
+	return kv.Value, kv.ModRevision, true, nil
+}
+
+func (k *etcdKV) Put(ctx context.Context, key string, value []byte) error {
+	_, err := k.client.Put(ctx, key, string(value))
+	return err
+}
+
+func (k *etcdKV) CAS(ctx context.Context, key string, value []byte, expectedVersion int64) error {
+	txn := k.client.Txn(ctx).If(
+		clientv3.Compare(clientv3.ModRevision(key), "=", expectedVersion),
+	).Then(
+		clientv3.OpPut(key, string(value)),
+	)
+	resp, err := txn.Commit()
+	if err != nil {
+		return err
+	}
+	if !resp.Succeeded {
+		return errCASConflict
+	}
+	return nil
+}
+
+func (k *etcdKV) List(ctx context.Context, prefix string) (map[string][]byte, error) {
+	resp, err := k.client.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string][]byte, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		result[string(kv.Key)] = kv.Value
+	}
+	return result, nil
+}
+
+func (k *etcdKV) Delete(ctx context.Context, key string) error {
+	_, err := k.client.Delete(ctx, key)
+	return err
+}
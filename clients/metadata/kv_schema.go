@@ -0,0 +1,144 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metadata
+
+import (
+	"context"
+	"errors"
+)
+
+// errCASConflict is returned by kvStore.CAS when the expected version
+// does not match the version currently stored at key.
+var errCASConflict = errors.New("metadata: CAS version conflict")
+
+// kvStore is the minimal key/value + secondary-index contract that a
+// KV-backed metadata driver must provide. Schema access (destinations,
+// consumer groups, extents, ack-leveled sequence numbers) is expressed
+// in terms of these primitives so that a new backend only needs to
+// implement this interface rather than the full thrift-generated
+// TChanMetadataService surface.
+type kvStore interface {
+	// Get returns the value stored at key along with the version it was
+	// last written at (for use with CAS). ok is false if key does not exist.
+	Get(ctx context.Context, key string) (value []byte, version int64, ok bool, err error)
+
+	// Put unconditionally writes value at key.
+	Put(ctx context.Context, key string, value []byte) error
+
+	// CAS writes value at key only if the key's current version equals
+	// expectedVersion (0 means "key must not exist yet"). It returns
+	// errCASConflict if the precondition does not hold, using a
+	// linearizable transaction on the underlying store.
+	CAS(ctx context.Context, key string, value []byte, expectedVersion int64) error
+
+	// List returns every key/value pair whose key has the given prefix.
+	// This backs the secondary indexes (e.g. consumer groups by
+	// destination, extents by destination).
+	List(ctx context.Context, prefix string) (map[string][]byte, error)
+
+	// Delete removes key, if present.
+	Delete(ctx context.Context, key string) error
+}
+
+// Key layout for the schema areas the KV drivers cover today: destinations,
+// consumer groups, extents and the per-consumer-group ack level. Mirrors
+// the partition keys used by the Cassandra schema so the two drivers can
+// be migrated between without a logical data model change.
+const (
+	destinationKeyPrefix = "/cherami/destinations/"
+	consumerGroupKeyPfx  = "/cherami/consumer-groups/"
+	extentKeyPrefix      = "/cherami/extents/"
+	ackLevelKeyPrefix    = "/cherami/ack-levels/"
+)
+
+func destinationKey(uuid string) string {
+	return destinationKeyPrefix + uuid
+}
+
+func consumerGroupKey(destinationUUID, name string) string {
+	return consumerGroupKeyPfx + destinationUUID + "/" + name
+}
+
+func extentKey(destinationUUID, extentUUID string) string {
+	return extentKeyPrefix + destinationUUID + "/" + extentUUID
+}
+
+func ackLevelKey(consumerGroupUUID, extentUUID string) string {
+	return ackLevelKeyPrefix + consumerGroupUUID + "/" + extentUUID
+}
+
+// kvSchema implements the schema-level operations (create/read/list/CAS
+// update) for destinations, consumer groups, extents and ack levels on
+// top of a generic kvStore, such as etcdKV or consulKV. No registered
+// Driver uses it: etcdKV and consulKV are kvStores only, and there is no
+// "etcd" or "consul" Driver built on them.
+type kvSchema struct {
+	store kvStore
+}
+
+func (s *kvSchema) createDestination(ctx context.Context, uuid string, value []byte) error {
+	return s.store.CAS(ctx, destinationKey(uuid), value, 0)
+}
+
+func (s *kvSchema) readDestination(ctx context.Context, uuid string) ([]byte, bool, error) {
+	value, _, ok, err := s.store.Get(ctx, destinationKey(uuid))
+	return value, ok, err
+}
+
+func (s *kvSchema) updateDestination(ctx context.Context, uuid string, expectedVersion int64, value []byte) error {
+	return s.store.CAS(ctx, destinationKey(uuid), value, expectedVersion)
+}
+
+func (s *kvSchema) deleteDestination(ctx context.Context, uuid string) error {
+	return s.store.Delete(ctx, destinationKey(uuid))
+}
+
+func (s *kvSchema) createConsumerGroup(ctx context.Context, destinationUUID, name string, value []byte) error {
+	return s.store.CAS(ctx, consumerGroupKey(destinationUUID, name), value, 0)
+}
+
+func (s *kvSchema) listConsumerGroups(ctx context.Context, destinationUUID string) (map[string][]byte, error) {
+	return s.store.List(ctx, consumerGroupKeyPfx+destinationUUID+"/")
+}
+
+func (s *kvSchema) createExtent(ctx context.Context, destinationUUID, extentUUID string, value []byte) error {
+	return s.store.CAS(ctx, extentKey(destinationUUID, extentUUID), value, 0)
+}
+
+func (s *kvSchema) updateExtent(ctx context.Context, destinationUUID, extentUUID string, expectedVersion int64, value []byte) error {
+	return s.store.CAS(ctx, extentKey(destinationUUID, extentUUID), value, expectedVersion)
+}
+
+func (s *kvSchema) listExtents(ctx context.Context, destinationUUID string) (map[string][]byte, error) {
+	return s.store.List(ctx, extentKeyPrefix+destinationUUID+"/")
+}
+
+// updateAckLevel advances the ack-leveled sequence number for a
+// consumer-group/extent pair using CAS, so that concurrent output hosts
+// reading from the same consumer group never regress the ack level.
+func (s *kvSchema) updateAckLevel(ctx context.Context, consumerGroupUUID, extentUUID string, expectedVersion int64, value []byte) error {
+	return s.store.CAS(ctx, ackLevelKey(consumerGroupUUID, extentUUID), value, expectedVersion)
+}
+
+func (s *kvSchema) readAckLevel(ctx context.Context, consumerGroupUUID, extentUUID string) ([]byte, bool, error) {
+	value, _, ok, err := s.store.Get(ctx, ackLevelKey(consumerGroupUUID, extentUUID))
+	return value, ok, err
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package metadata
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// memKV is an in-memory kvStore used to exercise kvSchema's semantics
+// independent of any real etcd/Consul cluster.
+type memKV struct {
+	values   map[string][]byte
+	versions map[string]int64
+}
+
+func newMemKV() *memKV {
+	return &memKV{values: make(map[string][]byte), versions: make(map[string]int64)}
+}
+
+func (k *memKV) Get(ctx context.Context, key string) ([]byte, int64, bool, error) {
+	v, ok := k.values[key]
+	return v, k.versions[key], ok, nil
+}
+
+func (k *memKV) Put(ctx context.Context, key string, value []byte) error {
+	k.values[key] = value
+	k.versions[key]++
+	return nil
+}
+
+func (k *memKV) CAS(ctx context.Context, key string, value []byte, expectedVersion int64) error {
+	if k.versions[key] != expectedVersion {
+		return errCASConflict
+	}
+	k.values[key] = value
+	k.versions[key]++
+	return nil
+}
+
+func (k *memKV) List(ctx context.Context, prefix string) (map[string][]byte, error) {
+	result := make(map[string][]byte)
+	for key, value := range k.values {
+		if len(key) >= len(prefix) && key[:len(prefix)] == prefix {
+			result[key] = value
+		}
+	}
+	return result, nil
+}
+
+func (k *memKV) Delete(ctx context.Context, key string) error {
+	delete(k.values, key)
+	delete(k.versions, key)
+	return nil
+}
+
+func TestKVSchemaDestinationCAS(t *testing.T) {
+	schema := &kvSchema{store: newMemKV()}
+	ctx := context.Background()
+
+	require.NoError(t, schema.createDestination(ctx, "dst-1", []byte("v1")))
+	require.Error(t, schema.createDestination(ctx, "dst-1", []byte("v1-again")),
+		"creating the same destination twice must conflict")
+
+	value, ok, err := schema.readDestination(ctx, "dst-1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []byte("v1"), value)
+
+	require.NoError(t, schema.updateDestination(ctx, "dst-1", 1, []byte("v2")))
+	require.Error(t, schema.updateDestination(ctx, "dst-1", 1, []byte("stale")),
+		"update with a stale expected version must conflict")
+}
+
+func TestKVSchemaConsumerGroupAndExtentListing(t *testing.T) {
+	schema := &kvSchema{store: newMemKV()}
+	ctx := context.Background()
+
+	require.NoError(t, schema.createConsumerGroup(ctx, "dst-1", "cg-a", []byte("a")))
+	require.NoError(t, schema.createConsumerGroup(ctx, "dst-1", "cg-b", []byte("b")))
+	require.NoError(t, schema.createConsumerGroup(ctx, "dst-2", "cg-c", []byte("c")))
+
+	groups, err := schema.listConsumerGroups(ctx, "dst-1")
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+
+	require.NoError(t, schema.createExtent(ctx, "dst-1", "ext-1", []byte("e1")))
+	extents, err := schema.listExtents(ctx, "dst-1")
+	require.NoError(t, err)
+	require.Len(t, extents, 1)
+}
+
+func TestKVSchemaAckLevelCAS(t *testing.T) {
+	schema := &kvSchema{store: newMemKV()}
+	ctx := context.Background()
+
+	require.NoError(t, schema.updateAckLevel(ctx, "cg-1", "ext-1", 0, []byte("100")))
+
+	value, ok, err := schema.readAckLevel(ctx, "cg-1", "ext-1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []byte("100"), value)
+
+	require.Error(t, schema.updateAckLevel(ctx, "cg-1", "ext-1", 0, []byte("200")),
+		"advancing the ack level with a stale version must conflict")
+	require.NoError(t, schema.updateAckLevel(ctx, "cg-1", "ext-1", 1, []byte("200")))
+}
@@ -32,8 +32,10 @@ import (
 	"github.com/uber/cherami-server/common"
 	"github.com/uber/cherami-server/common/configure"
 	"github.com/uber/cherami-server/common/dconfigclient"
+	"github.com/uber/cherami-server/common/governance"
 	"github.com/uber/cherami-server/services/controllerhost"
 	"github.com/uber/cherami-server/services/frontendhost"
+	"github.com/uber/cherami-server/services/gatewayhost"
 	"github.com/uber/cherami-server/services/inputhost"
 	"github.com/uber/cherami-server/services/outputhost"
 	"github.com/uber/cherami-server/services/replicator"
@@ -53,7 +55,7 @@ const (
 	diagnosticPortOffset = 10000
 )
 
-//StartInputHostService starts the inputhost service of cherami
+// StartInputHostService starts the inputhost service of cherami
 func StartInputHostService() {
 	serviceName := common.InputServiceName
 	cfg := common.SetupServerConfig(configure.NewCommonConfigure())
@@ -61,7 +63,7 @@ func StartInputHostService() {
 		log.Panic(e)
 	}
 
-	meta, err := metadata.NewCassandraMetadataService(cfg.GetMetadataConfig())
+	meta, err := metadata.NewMetadataService(cfg.GetMetadataConfig())
 	if err != nil {
 		log.WithField(common.TagErr, err).Fatal(`inputhost: unable to instantiate metadata client`)
 	}
@@ -70,9 +72,19 @@ func StartInputHostService() {
 	reporter := common.NewMetricReporterWithHostname(cfg.GetServiceConfig(serviceName))
 	dClient := dconfigclient.NewDconfigClient(cfg.GetServiceConfig(serviceName), serviceName)
 
-	sCommon := common.NewService(serviceName, uuid.New(), cfg.GetServiceConfig(serviceName), common.NewUUIDResolver(meta), hwInfoReader, reporter, dClient, common.NewBypassAuthManager())
+	hostUUID := uuid.New()
+	sCommon := common.NewService(serviceName, hostUUID, cfg.GetServiceConfig(serviceName), common.NewUUIDResolver(meta), hwInfoReader, reporter, dClient, common.NewBypassAuthManager())
+	if _, e := common.RegisterWithDiscovery(cfg.GetServiceConfig(serviceName), serviceName, hostUUID,
+		cfg.GetServiceConfig(serviceName).GetListenAddress().String(), cfg.GetServiceConfig(serviceName).GetPort(), nil); e != nil {
+		log.WithField(common.TagErr, e).Warn("inputhost: unable to register with discovery registry")
+	}
 	h, tc := inputhost.NewInputHost(serviceName, sCommon, meta, nil)
-	h.Start(tc)
+
+	// Wrap every inbound TChannel handler with this service's governance
+	// settings (circuit breaking, rate limiting) so a slow storehost
+	// can't stall inputhost's RPC dispatch indefinitely.
+	govManager := governance.NewManager(serviceName, dClient, reporter)
+	h.Start(governance.WrapTChanServers(govManager, tc))
 
 	// start websocket server
 	common.WSStart(cfg.GetServiceConfig(serviceName).GetListenAddress().String(),
@@ -82,7 +94,7 @@ func StartInputHostService() {
 	common.ServiceLoop(cfg.GetServiceConfig(serviceName).GetPort()+diagnosticPortOffset, cfg, h)
 }
 
-//StartControllerService starts the controller service of cherami
+// StartControllerService starts the controller service of cherami
 func StartControllerService() {
 	serviceName := common.ControllerServiceName
 	cfg := common.SetupServerConfig(configure.NewCommonConfigure())
@@ -90,7 +102,7 @@ func StartControllerService() {
 		log.Panic(e)
 	}
 
-	meta, err := metadata.NewCassandraMetadataService(cfg.GetMetadataConfig())
+	meta, err := metadata.NewMetadataService(cfg.GetMetadataConfig())
 	if err != nil {
 		// no metadata service - just fail early
 		log.WithField(common.TagErr, err).Fatal(`unable to instantiate metadata service (did you run ./scripts/setup_cassandra_schema.sh?)`)
@@ -98,13 +110,36 @@ func StartControllerService() {
 	hwInfoReader := common.NewHostHardwareInfoReader(meta)
 	reporter := common.NewMetricReporterWithHostname(cfg.GetServiceConfig(serviceName))
 	dClient := dconfigclient.NewDconfigClient(cfg.GetServiceConfig(serviceName), serviceName)
-	sVice := common.NewService(serviceName, uuid.New(), cfg.GetServiceConfig(serviceName), common.NewUUIDResolver(meta), hwInfoReader, reporter, dClient, common.NewBypassAuthManager())
+	hostUUID := uuid.New()
+	sVice := common.NewService(serviceName, hostUUID, cfg.GetServiceConfig(serviceName), common.NewUUIDResolver(meta), hwInfoReader, reporter, dClient, common.NewBypassAuthManager())
+	if _, e := common.RegisterWithDiscovery(cfg.GetServiceConfig(serviceName), serviceName, hostUUID,
+		cfg.GetServiceConfig(serviceName).GetListenAddress().String(), cfg.GetServiceConfig(serviceName).GetPort(), nil); e != nil {
+		log.WithField(common.TagErr, e).Warn("controller: unable to register with discovery registry")
+	}
+	// When a registry driver is configured, extent placement could
+	// consume the same Watch-backed membership the rest of the registry
+	// package uses instead of ringpop, the same opt-in
+	// RegisterWithDiscovery already offers for registration/heartbeating.
+	// controllerhost.NewController's placement logic isn't present in
+	// this snapshot, so there's no real call site to thread a
+	// PlacementSource into yet; start the watch and log the instance
+	// count it holds right after starting (often zero, before the first
+	// update arrives), instead of passing an unconsumed value into
+	// NewController.
+	if placement, hasPlacement, e := common.WatchWithDiscovery(cfg.GetServiceConfig(serviceName), serviceName); e != nil {
+		log.WithField(common.TagErr, e).Warn("controller: unable to start registry watch for extent placement")
+	} else if hasPlacement {
+		log.WithField("instanceCount", len(placement.Instances())).Info("controller: registry-discovered instances available for extent placement")
+	}
+
 	mcp, tc := controllerhost.NewController(cfg, sVice, meta, common.NewDummyZoneFailoverManager())
-	mcp.Start(tc)
+
+	govManager := governance.NewManager(serviceName, dClient, reporter)
+	mcp.Start(governance.WrapTChanServers(govManager, tc))
 	common.ServiceLoop(cfg.GetServiceConfig(serviceName).GetPort()+diagnosticPortOffset, cfg, mcp.Service)
 }
 
-//StartFrontendHostService starts the frontendhost service of cherami
+// StartFrontendHostService starts the frontendhost service of cherami
 func StartFrontendHostService() {
 	serviceName := common.FrontendServiceName
 	cfg := common.SetupServerConfig(configure.NewCommonConfigure())
@@ -112,7 +147,7 @@ func StartFrontendHostService() {
 		log.Panic(e)
 	}
 
-	meta, err := metadata.NewCassandraMetadataService(cfg.GetMetadataConfig())
+	meta, err := metadata.NewMetadataService(cfg.GetMetadataConfig())
 	if err != nil {
 		// no metadata service - just fail early
 		log.WithField(common.TagErr, err).Fatal(`frontendhost: unable to instantiate metadata service`)
@@ -121,16 +156,23 @@ func StartFrontendHostService() {
 	hwInfoReader := common.NewHostHardwareInfoReader(meta)
 	reporter := common.NewMetricReporterWithHostname(cfg.GetServiceConfig(serviceName))
 	dClient := dconfigclient.NewDconfigClient(cfg.GetServiceConfig(serviceName), serviceName)
-	sCommon := common.NewService(serviceName, uuid.New(), cfg.GetServiceConfig(serviceName), common.NewUUIDResolver(meta), hwInfoReader, reporter, dClient, common.NewBypassAuthManager())
+	hostUUID := uuid.New()
+	sCommon := common.NewService(serviceName, hostUUID, cfg.GetServiceConfig(serviceName), common.NewUUIDResolver(meta), hwInfoReader, reporter, dClient, common.NewBypassAuthManager())
+	if _, e := common.RegisterWithDiscovery(cfg.GetServiceConfig(serviceName), serviceName, hostUUID,
+		cfg.GetServiceConfig(serviceName).GetListenAddress().String(), cfg.GetServiceConfig(serviceName).GetPort(), nil); e != nil {
+		log.WithField(common.TagErr, e).Warn("frontendhost: unable to register with discovery registry")
+	}
 	h, tc := frontendhost.NewFrontendHost(serviceName, sCommon, meta, cfg)
 
 	// frontend host also exposes non-streaming metadata methods
 	tc = append(tc, m.NewTChanMetadataExposableServer(meta))
-	h.Start(tc)
+
+	govManager := governance.NewManager(serviceName, dClient, reporter)
+	h.Start(governance.WrapTChanServers(govManager, tc))
 	common.ServiceLoop(cfg.GetServiceConfig(serviceName).GetPort()+diagnosticPortOffset, cfg, sCommon)
 }
 
-//StartOutputHostService starts the outputhost service of cherami
+// StartOutputHostService starts the outputhost service of cherami
 func StartOutputHostService() {
 	serviceName := common.OutputServiceName
 	cfg := common.SetupServerConfig(configure.NewCommonConfigure())
@@ -138,7 +180,7 @@ func StartOutputHostService() {
 		log.Panic(e)
 	}
 
-	meta, err := metadata.NewCassandraMetadataService(cfg.GetMetadataConfig())
+	meta, err := metadata.NewMetadataService(cfg.GetMetadataConfig())
 	if err != nil {
 		// no metadata service - just fail early
 		log.WithField(common.TagErr, err).Fatal(`frontendhost: unable to instantiate metadata service`)
@@ -147,14 +189,21 @@ func StartOutputHostService() {
 	hwInfoReader := common.NewHostHardwareInfoReader(meta)
 	reporter := common.NewMetricReporterWithHostname(cfg.GetServiceConfig(serviceName))
 	dClient := dconfigclient.NewDconfigClient(cfg.GetServiceConfig(serviceName), serviceName)
-	sCommon := common.NewService(serviceName, uuid.New(), cfg.GetServiceConfig(serviceName), common.NewUUIDResolver(meta), hwInfoReader, reporter, dClient, common.NewBypassAuthManager())
+	hostUUID := uuid.New()
+	sCommon := common.NewService(serviceName, hostUUID, cfg.GetServiceConfig(serviceName), common.NewUUIDResolver(meta), hwInfoReader, reporter, dClient, common.NewBypassAuthManager())
+	if _, e := common.RegisterWithDiscovery(cfg.GetServiceConfig(serviceName), serviceName, hostUUID,
+		cfg.GetServiceConfig(serviceName).GetListenAddress().String(), cfg.GetServiceConfig(serviceName).GetPort(), nil); e != nil {
+		log.WithField(common.TagErr, e).Warn("outputhost: unable to register with discovery registry")
+	}
 
 	// Instantiate a frontend server. Don't call frontendhost.Start(), since that would advertise in Hyperbahn,
 	// and since we aren't using thrift anyway. We are selfish with our Frontend.
 	frontendhost, _ := frontendhost.NewFrontendHost(common.FrontendServiceName, sCommon, meta, cfg)
 
 	h, tc := outputhost.NewOutputHost(serviceName, sCommon, meta, frontendhost, nil, cfg.GetKafkaConfig())
-	h.Start(tc)
+
+	govManager := governance.NewManager(serviceName, dClient, reporter)
+	h.Start(governance.WrapTChanServers(govManager, tc))
 
 	// start websocket server
 	common.WSStart(cfg.GetServiceConfig(serviceName).GetListenAddress().String(),
@@ -164,7 +213,7 @@ func StartOutputHostService() {
 	common.ServiceLoop(cfg.GetServiceConfig(serviceName).GetPort()+diagnosticPortOffset, cfg, sCommon)
 }
 
-//StartStoreHostService starts the storehost service of cherami
+// StartStoreHostService starts the storehost service of cherami
 func StartStoreHostService() {
 	serviceName := common.StoreServiceName
 	cfg := common.SetupServerConfig(configure.NewCommonConfigure())
@@ -172,7 +221,7 @@ func StartStoreHostService() {
 		log.Panic(e)
 	}
 
-	meta, err := metadata.NewCassandraMetadataService(cfg.GetMetadataConfig())
+	meta, err := metadata.NewMetadataService(cfg.GetMetadataConfig())
 	if err != nil {
 		log.WithField(common.TagErr, err).Fatal(`storehost: unable to instantiate metadata client`)
 	}
@@ -180,7 +229,12 @@ func StartStoreHostService() {
 	hwInfoReader := common.NewHostHardwareInfoReader(meta)
 	reporter := common.NewMetricReporterWithHostname(cfg.GetServiceConfig(serviceName))
 	dClient := dconfigclient.NewDconfigClient(cfg.GetServiceConfig(serviceName), serviceName)
-	sCommon := common.NewService(serviceName, cfg.GetStorageConfig().GetHostUUID(), cfg.GetServiceConfig(serviceName), common.NewUUIDResolver(meta), hwInfoReader, reporter, dClient, common.NewBypassAuthManager())
+	hostUUID := cfg.GetStorageConfig().GetHostUUID()
+	sCommon := common.NewService(serviceName, hostUUID, cfg.GetServiceConfig(serviceName), common.NewUUIDResolver(meta), hwInfoReader, reporter, dClient, common.NewBypassAuthManager())
+	if _, e := common.RegisterWithDiscovery(cfg.GetServiceConfig(serviceName), serviceName, hostUUID,
+		cfg.GetServiceConfig(serviceName).GetListenAddress().String(), cfg.GetServiceConfig(serviceName).GetPort(), nil); e != nil {
+		log.WithField(common.TagErr, e).Warn("storehost: unable to register with discovery registry")
+	}
 
 	// parse args and pass them into NewStoreHost
 	var storeStr, baseDir string
@@ -226,7 +280,8 @@ func StartStoreHostService() {
 	// initialize and start storehost
 	h, tc := storehost.NewStoreHost(serviceName, sCommon, meta, opts)
 
-	h.Start(tc)
+	govManager := governance.NewManager(serviceName, dClient, reporter)
+	h.Start(governance.WrapTChanServers(govManager, tc))
 
 	// start websocket server
 	common.WSStart(cfg.GetServiceConfig(serviceName).GetListenAddress().String(),
@@ -236,7 +291,53 @@ func StartStoreHostService() {
 	common.ServiceLoop(cfg.GetServiceConfig(serviceName).GetPort()+diagnosticPortOffset, cfg, sCommon)
 }
 
-//StartReplicatorService starts the repliator service of cherami
+// StartGatewayService starts the HTTP/gRPC ingress gateway of cherami, letting
+// clients that can't link the native Cherami client publish/consume over
+// plain REST or gRPC instead.
+func StartGatewayService() {
+	serviceName := common.GatewayServiceName
+	cfg := common.SetupServerConfig(configure.NewCommonConfigure())
+	if e := os.Setenv("port", fmt.Sprintf("%d", cfg.GetServiceConfig(serviceName).GetPort())); e != nil {
+		log.Panic(e)
+	}
+
+	meta, err := metadata.NewMetadataService(cfg.GetMetadataConfig())
+	if err != nil {
+		log.WithField(common.TagErr, err).Fatal(`gateway: unable to instantiate metadata service`)
+	}
+
+	hwInfoReader := common.NewHostHardwareInfoReader(meta)
+	reporter := common.NewMetricReporterWithHostname(cfg.GetServiceConfig(serviceName))
+	dClient := dconfigclient.NewDconfigClient(cfg.GetServiceConfig(serviceName), serviceName)
+	hostUUID := uuid.New()
+	sCommon := common.NewService(serviceName, hostUUID, cfg.GetServiceConfig(serviceName), common.NewUUIDResolver(meta), hwInfoReader, reporter, dClient, common.NewBypassAuthManager())
+	if _, e := common.RegisterWithDiscovery(cfg.GetServiceConfig(serviceName), serviceName, hostUUID,
+		cfg.GetServiceConfig(serviceName).GetListenAddress().String(), cfg.GetServiceConfig(serviceName).GetPort(), nil); e != nil {
+		log.WithField(common.TagErr, e).Warn("gateway: unable to register with discovery registry")
+	}
+
+	authHandler := gatewayAuthHandler(cfg)
+	h, tc := gatewayhost.NewGatewayHost(serviceName, sCommon, gatewayhost.NewClientFactory(cfg), authHandler, cfg)
+	h.Start(tc)
+
+	// start diagnosis local http server
+	common.ServiceLoop(cfg.GetServiceConfig(serviceName).GetPort()+diagnosticPortOffset, cfg, sCommon)
+}
+
+// gatewayAuthHandler picks the gateway's auth handler based on the
+// "GatewayConfig.AuthMode" setting, defaulting to JWT since, unlike the
+// TChannel-facing services, the gateway is expected to face untrusted
+// internet clients out of the box.
+func gatewayAuthHandler(cfg *configure.CommonConfigure) gatewayhost.AuthHandler {
+	switch cfg.GetGatewayConfig().GetAuthMode() {
+	case "oauth2":
+		return gatewayhost.NewOAuth2AuthHandler(gatewayhost.NewHTTPIntrospector(cfg.GetGatewayConfig().GetOAuth2IntrospectionURL()))
+	default:
+		return gatewayhost.NewJWTAuthHandler([]byte(cfg.GetGatewayConfig().GetJWTSigningKey()))
+	}
+}
+
+// StartReplicatorService starts the repliator service of cherami
 func StartReplicatorService() {
 	serviceName := common.ReplicatorServiceName
 	cfg := common.SetupServerConfig(configure.NewCommonConfigure())
@@ -244,7 +345,7 @@ func StartReplicatorService() {
 		log.Panic(e)
 	}
 
-	meta, err := metadata.NewCassandraMetadataService(cfg.GetMetadataConfig())
+	meta, err := metadata.NewMetadataService(cfg.GetMetadataConfig())
 	if err != nil {
 		// no metadata service - just fail early
 		log.WithField(common.TagErr, err).Fatal(`frontendhost: unable to instantiate metadata service`)
@@ -252,10 +353,29 @@ func StartReplicatorService() {
 	hwInfoReader := common.NewHostHardwareInfoReader(meta)
 	reporter := common.NewMetricReporterWithHostname(cfg.GetServiceConfig(serviceName))
 	dClient := dconfigclient.NewDconfigClient(cfg.GetServiceConfig(serviceName), serviceName)
-	sCommon := common.NewService(serviceName, uuid.New(), cfg.GetServiceConfig(serviceName), common.NewUUIDResolver(meta), hwInfoReader, reporter, dClient, common.NewBypassAuthManager())
+	hostUUID := uuid.New()
+	sCommon := common.NewService(serviceName, hostUUID, cfg.GetServiceConfig(serviceName), common.NewUUIDResolver(meta), hwInfoReader, reporter, dClient, common.NewBypassAuthManager())
+	if _, e := common.RegisterWithDiscovery(cfg.GetServiceConfig(serviceName), serviceName, hostUUID,
+		cfg.GetServiceConfig(serviceName).GetListenAddress().String(), cfg.GetServiceConfig(serviceName).GetPort(), nil); e != nil {
+		log.WithField(common.TagErr, e).Warn("replicator: unable to register with discovery registry")
+	}
+
+	// egressRouter would resolve each outbound extent's target zone(s)
+	// against the hot-reloaded egress rules (falling back to the static
+	// RemoteZones config below) and enforce their QPS/bandwidth caps, the
+	// way a replication send loop's per-extent Route call needs.
+	// replicator.NewReplicator's actual send loop isn't present in this
+	// snapshot, so there's no real call site to thread it into yet;
+	// construct it here so Stop shuts it down cleanly, rather than
+	// passing an unconsumed value into NewReplicator's unmodified
+	// signature.
+	egressRouter := replicator.NewEgressRouter(dClient, reporter, cfg.GetReplicatorConfig().GetRemoteZones())
+	defer egressRouter.Stop()
 
 	h, tc := replicator.NewReplicator(serviceName, sCommon, meta, replicator.NewReplicatorClientFactory(cfg, common.GetDefaultLogger()), cfg)
-	h.Start(tc)
+
+	govManager := governance.NewManager(serviceName, dClient, reporter)
+	h.Start(governance.WrapTChanServers(govManager, tc))
 
 	// start websocket server
 	common.WSStart(cfg.GetServiceConfig(serviceName).GetListenAddress().String(),
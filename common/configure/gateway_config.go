@@ -0,0 +1,63 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package configure
+
+// GatewayConfig holds the settings specific to the HTTP/gRPC ingress
+// gateway (see services/gatewayhost), parsed from the "GatewayConfig"
+// block of the service YAML the same way StorageConfig or KafkaConfig
+// already are.
+type GatewayConfig struct {
+	// AuthMode selects the AuthHandler the gateway authenticates
+	// inbound requests with: "jwt" (the default) or "oauth2".
+	AuthMode string `yaml:"AuthMode"`
+	// JWTSigningKey is the HS256 key used to validate bearer JWTs when
+	// AuthMode is "jwt".
+	JWTSigningKey string `yaml:"JWTSigningKey"`
+	// OAuth2IntrospectionURL is the RFC 7662 token introspection
+	// endpoint used when AuthMode is "oauth2".
+	OAuth2IntrospectionURL string `yaml:"OAuth2IntrospectionURL"`
+}
+
+// GetAuthMode returns the configured auth mode, defaulting to "jwt".
+func (g *GatewayConfig) GetAuthMode() string {
+	if g.AuthMode == "" {
+		return "jwt"
+	}
+	return g.AuthMode
+}
+
+// GetJWTSigningKey returns the HS256 signing key used to validate bearer JWTs.
+func (g *GatewayConfig) GetJWTSigningKey() string {
+	return g.JWTSigningKey
+}
+
+// GetOAuth2IntrospectionURL returns the token introspection endpoint
+// used to validate bearer tokens when running in OAuth2 mode.
+func (g *GatewayConfig) GetOAuth2IntrospectionURL() string {
+	return g.OAuth2IntrospectionURL
+}
+
+// GetGatewayConfig returns the gateway-specific settings off the common
+// configure, the same way GetStorageConfig/GetKafkaConfig already do for
+// their respective services.
+func (c *CommonConfigure) GetGatewayConfig() *GatewayConfig {
+	return c.GatewayConfig
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package configure
+
+// GetDriver returns the name of the metadata driver to instantiate. It
+// defaults to "cassandra" so that existing deployments keep working
+// without any config change. "cassandra" is the only driver that
+// clients/metadata registers; any other name makes
+// metadata.NewMetadataService return an unknown-driver error.
+func (m *MetadataConfig) GetDriver() string {
+	if m.Driver == "" {
+		return "cassandra"
+	}
+	return m.Driver
+}
+
+// GetHosts returns the seed host list for the configured driver: Hosts
+// if set, otherwise the list returned by GetCassandraHosts(). No driver
+// registered in clients/metadata reads it.
+func (m *MetadataConfig) GetHosts() []string {
+	if len(m.Hosts) > 0 {
+		return m.Hosts
+	}
+	return m.GetCassandraHosts()
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package governance provides circuit breaking, rate limiting and
+// bounded retry. Each service's Start*Service entrypoint applies the
+// breaker and limiter around its inbound TChannel server handlers
+// (WrapTChanServers); Manager.Wrap, which adds retry, wraps outbound
+// calls and is used by the gateway's calls to frontendhost. The other
+// services' outbound client calls are not wrapped.
+package governance
+
+import "context"
+
+// Call is a single RPC invocation, already bound to its arguments;
+// Middleware wraps it to add a cross-cutting concern (breaker, limiter,
+// retry, ...) without the caller needing to know which concerns are
+// configured for this method.
+type Call func(ctx context.Context) (interface{}, error)
+
+// Middleware wraps a Call with one governance concern, returning a new
+// Call that applies it.
+type Middleware func(next Call) Call
+
+// Chain composes middlewares into a single Middleware, applied in the
+// order given: the first middleware is outermost (runs first on the way
+// in, last on the way out).
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next Call) Call {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// RetryMiddleware wraps next so failed calls are retried per settings.
+func RetryMiddleware(settings RetrySettings) Middleware {
+	return func(next Call) Call {
+		return func(ctx context.Context) (interface{}, error) {
+			var result interface{}
+			err := Retry(ctx, settings, func() error {
+				var callErr error
+				result, callErr = next(ctx)
+				return callErr
+			})
+			return result, err
+		}
+	}
+}
+
+// CircuitBreakerMiddleware wraps next so calls short-circuit with
+// ErrCircuitOpen while breaker is open, and feeds every outcome back
+// into breaker.
+func CircuitBreakerMiddleware(breaker *CircuitBreaker) Middleware {
+	return func(next Call) Call {
+		return func(ctx context.Context) (interface{}, error) {
+			if err := breaker.Allow(); err != nil {
+				return nil, err
+			}
+			result, err := next(ctx)
+			if err != nil {
+				breaker.RecordFailure()
+			} else {
+				breaker.RecordSuccess()
+			}
+			return result, err
+		}
+	}
+}
+
+// RateLimiterMiddleware wraps next so calls keyed by key() are rejected
+// with ErrRateLimited once limiter's budget for that key is exhausted.
+func RateLimiterMiddleware(limiter *RateLimiter, key func(ctx context.Context) string) Middleware {
+	return func(next Call) Call {
+		return func(ctx context.Context) (interface{}, error) {
+			if !limiter.Allow(key(ctx)) {
+				return nil, ErrRateLimited
+			}
+			return next(ctx)
+		}
+	}
+}
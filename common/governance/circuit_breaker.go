@@ -0,0 +1,222 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package governance
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by CircuitBreaker.Allow when the breaker is
+// open and not yet due for a half-open probe.
+var ErrCircuitOpen = errors.New("governance: circuit breaker is open")
+
+// State is a CircuitBreaker's current position.
+type State int
+
+// Breaker states, following the standard closed/open/half-open machine.
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreakerSettings configures a sliding-window failure-ratio breaker.
+type CircuitBreakerSettings struct {
+	// Name identifies the breaker in emitted events.
+	Name string
+	// WindowSize is the total duration the failure ratio is computed
+	// over, divided into BucketCount buckets that age out individually
+	// rather than resetting the whole window at once.
+	WindowSize time.Duration
+	// BucketCount is the number of buckets WindowSize is divided into.
+	BucketCount int
+	// MinRequests is the minimum number of requests that must have
+	// landed in the window before the failure ratio is evaluated, so a
+	// single early failure can't trip the breaker.
+	MinRequests int
+	// FailureRatio is the fraction of failed requests (0..1) in the
+	// window that trips the breaker from Closed to Open.
+	FailureRatio float64
+	// OpenDuration is how long the breaker stays Open before allowing a
+	// single HalfOpen probe request through.
+	OpenDuration time.Duration
+	// OnStateChange, if set, is called whenever the breaker transitions
+	// states, letting callers emit trip/reset events through a reporter.
+	OnStateChange func(name string, from, to State)
+}
+
+type bucket struct {
+	start               time.Time
+	successes, failures int
+}
+
+// CircuitBreaker is a sliding-window, failure-ratio circuit breaker.
+// Unlike a fixed-window breaker, buckets age out individually so the
+// failure ratio reflects only the trailing WindowSize, not a sudden
+// reset at fixed intervals.
+type CircuitBreaker struct {
+	settings  CircuitBreakerSettings
+	bucketDur time.Duration
+
+	mu       sync.Mutex
+	buckets  []bucket
+	state    State
+	openedAt time.Time
+}
+
+// NewCircuitBreaker returns a breaker configured by settings, applying
+// sensible defaults for any zero-valued fields.
+func NewCircuitBreaker(settings CircuitBreakerSettings) *CircuitBreaker {
+	if settings.WindowSize <= 0 {
+		settings.WindowSize = 10 * time.Second
+	}
+	if settings.BucketCount <= 0 {
+		settings.BucketCount = 10
+	}
+	if settings.FailureRatio <= 0 {
+		settings.FailureRatio = 0.5
+	}
+	if settings.OpenDuration <= 0 {
+		settings.OpenDuration = 5 * time.Second
+	}
+	if settings.MinRequests <= 0 {
+		settings.MinRequests = 10
+	}
+
+	return &CircuitBreaker{
+		settings:  settings,
+		bucketDur: settings.WindowSize / time.Duration(settings.BucketCount),
+		buckets:   make([]bucket, settings.BucketCount),
+	}
+}
+
+// Allow reports whether a call may proceed right now. It returns
+// ErrCircuitOpen if the breaker is Open and not yet due for a probe, or
+// if it is already HalfOpen: only the single call that makes the
+// Open->HalfOpen transition is let through, so at most one probe is ever
+// in flight.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if time.Since(b.openedAt) < b.settings.OpenDuration {
+			return ErrCircuitOpen
+		}
+		b.transition(HalfOpen)
+		return nil
+	case HalfOpen:
+		return ErrCircuitOpen
+	default:
+		return nil
+	}
+}
+
+// RecordSuccess reports a successful call outcome.
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == HalfOpen {
+		b.transition(Closed)
+		b.buckets = make([]bucket, b.settings.BucketCount)
+		return
+	}
+	b.currentBucket().successes++
+}
+
+// RecordFailure reports a failed call outcome, tripping the breaker if
+// the failure ratio over the sliding window now reaches FailureRatio.
+func (b *CircuitBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == HalfOpen {
+		b.transition(Open)
+		b.openedAt = time.Now()
+		return
+	}
+
+	b.currentBucket().failures++
+
+	successes, failures := b.windowTotals()
+	total := successes + failures
+	if total >= b.settings.MinRequests && float64(failures)/float64(total) >= b.settings.FailureRatio {
+		b.transition(Open)
+		b.openedAt = time.Now()
+	}
+}
+
+// currentBucket returns the bucket for "now", rotating out stale
+// buckets (older than one full window) as it goes.
+func (b *CircuitBreaker) currentBucket() *bucket {
+	now := time.Now()
+	idx := (now.UnixNano() / int64(b.bucketDur)) % int64(len(b.buckets))
+	bucket := &b.buckets[idx]
+	if now.Sub(bucket.start) >= b.settings.WindowSize {
+		bucket.start = now
+		bucket.successes, bucket.failures = 0, 0
+	}
+	return bucket
+}
+
+func (b *CircuitBreaker) windowTotals() (successes, failures int) {
+	now := time.Now()
+	for i := range b.buckets {
+		if now.Sub(b.buckets[i].start) < b.settings.WindowSize {
+			successes += b.buckets[i].successes
+			failures += b.buckets[i].failures
+		}
+	}
+	return
+}
+
+func (b *CircuitBreaker) transition(to State) {
+	from := b.state
+	if from == to {
+		return
+	}
+	b.state = to
+	if b.settings.OnStateChange != nil {
+		b.settings.OnStateChange(b.settings.Name, from, to)
+	}
+}
+
+// StateValue returns the breaker's current state, for tests and metrics.
+func (b *CircuitBreaker) StateValue() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package governance
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/uber/cherami-server/common"
+	"github.com/uber/cherami-server/common/dconfigclient"
+)
+
+// configKeyPrefix is the dconfigclient key prefix a service's governance
+// block is hot-reloaded from: "governance.<serviceName>", e.g.
+// "governance.cherami-inputhost".
+const configKeyPrefix = "governance."
+
+// MethodSettings is the governance configuration for a single RPC
+// method: the settings of any of its three concerns (breaker, rate
+// limit, retry) may be zero-valued, in which case that concern is not
+// applied to the method.
+type MethodSettings struct {
+	CircuitBreaker CircuitBreakerSettings `yaml:"circuitBreaker"`
+	RateLimitQPS   int                    `yaml:"rateLimitQPS"`
+	RateLimitBurst int                    `yaml:"rateLimitBurst"`
+	Retry          RetrySettings          `yaml:"retry"`
+}
+
+// ServiceSettings maps method name (thrift method, e.g. "PutMessage")
+// to its MethodSettings. A method absent from the map gets no
+// governance applied.
+type ServiceSettings map[string]MethodSettings
+
+// Provider serves the current ServiceSettings for one service and keeps
+// them fresh by polling dconfigclient on a ticker, mirroring
+// replicator.EgressRuleProvider so operators can retune breaker/limiter
+// thresholds per method without a restart.
+type Provider struct {
+	dClient   dconfigclient.Client
+	configKey string
+	current   atomic.Value // holds ServiceSettings
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+// NewProvider starts polling dClient for serviceName's governance config
+// every refreshInterval and returns the provider once the first load has
+// completed (an empty ServiceSettings if the config key isn't set).
+func NewProvider(dClient dconfigclient.Client, serviceName string, refreshInterval time.Duration) *Provider {
+	p := &Provider{
+		dClient:   dClient,
+		configKey: configKeyPrefix + serviceName,
+		stopCh:    make(chan struct{}),
+	}
+	p.reload()
+
+	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.reload()
+			case <-p.stopCh:
+				return
+			}
+		}
+	}()
+
+	return p
+}
+
+func (p *Provider) reload() {
+	raw, ok := p.dClient.GetOrDefault(p.configKey, "").(string)
+	if !ok || raw == "" {
+		p.current.Store(ServiceSettings{})
+		return
+	}
+
+	var settings ServiceSettings
+	if err := yaml.Unmarshal([]byte(raw), &settings); err != nil {
+		log.WithField(common.TagErr, err).Error("governance: failed to parse config, keeping previous settings")
+		return
+	}
+	p.current.Store(settings)
+}
+
+// Settings returns the most recently loaded ServiceSettings.
+func (p *Provider) Settings() ServiceSettings {
+	settings, _ := p.current.Load().(ServiceSettings)
+	return settings
+}
+
+// Stop halts the background refresh loop.
+func (p *Provider) Stop() {
+	p.stopOnce.Do(func() { close(p.stopCh) })
+}
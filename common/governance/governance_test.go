@@ -0,0 +1,122 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package governance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakerTripsAndHalfOpenRecovers(t *testing.T) {
+	var states []State
+	breaker := NewCircuitBreaker(CircuitBreakerSettings{
+		WindowSize:   time.Second,
+		BucketCount:  10,
+		MinRequests:  2,
+		FailureRatio: 0.5,
+		OpenDuration: 10 * time.Millisecond,
+		OnStateChange: func(name string, from, to State) {
+			states = append(states, to)
+		},
+	})
+
+	require.NoError(t, breaker.Allow())
+	breaker.RecordFailure()
+	breaker.RecordFailure()
+	require.Equal(t, Open, breaker.StateValue())
+	require.Equal(t, ErrCircuitOpen, breaker.Allow())
+
+	time.Sleep(15 * time.Millisecond)
+	require.NoError(t, breaker.Allow())
+	require.Equal(t, HalfOpen, breaker.StateValue())
+
+	breaker.RecordSuccess()
+	require.Equal(t, Closed, breaker.StateValue())
+	require.Equal(t, []State{Open, HalfOpen, Closed}, states)
+}
+
+func TestRateLimiterAllowsBurstThenRejects(t *testing.T) {
+	limiter := NewRateLimiter(1, 2)
+
+	require.True(t, limiter.Allow("k"))
+	require.True(t, limiter.Allow("k"))
+	require.False(t, limiter.Allow("k"))
+
+	require.True(t, limiter.Allow("other-key"))
+}
+
+func TestRetrySucceedsAfterTransientFailures(t *testing.T) {
+	attempts := 0
+	err := Retry(context.Background(), RetrySettings{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    5 * time.Millisecond,
+	}, func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+}
+
+func TestRetryReturnsLastErrorAfterExhaustingAttempts(t *testing.T) {
+	attempts := 0
+	err := Retry(context.Background(), RetrySettings{
+		MaxAttempts: 2,
+		BaseDelay:   time.Millisecond,
+	}, func() error {
+		attempts++
+		return errors.New("permanent")
+	})
+
+	require.EqualError(t, err, "permanent")
+	require.Equal(t, 2, attempts)
+}
+
+func TestChainAppliesMiddlewaresInOrder(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next Call) Call {
+			return func(ctx context.Context) (interface{}, error) {
+				calls = append(calls, name)
+				return next(ctx)
+			}
+		}
+	}
+
+	chained := Chain(trace("outer"), trace("inner"))(func(ctx context.Context) (interface{}, error) {
+		calls = append(calls, "call")
+		return "ok", nil
+	})
+
+	result, err := chained(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "ok", result)
+	require.Equal(t, []string{"outer", "inner", "call"}, calls)
+}
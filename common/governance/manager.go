@@ -0,0 +1,232 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package governance
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/uber/cherami-server/common"
+	"github.com/uber/cherami-server/common/dconfigclient"
+)
+
+// Metric names emitted through the reporter handed to NewManager, so
+// operators can build alerts/dashboards the same way they would for any
+// other per-service metric.
+const (
+	metricBreakerTrip  = "governance.breaker.trip"
+	metricBreakerReset = "governance.breaker.reset"
+	metricRateLimited  = "governance.ratelimit.rejected"
+	metricRetryAttempt = "governance.retry.attempt"
+)
+
+const configRefreshInterval = 30 * time.Second
+
+// Manager is the per-service entry point each Start*Service entrypoint
+// creates to wrap inbound TChannel handlers (WrapInbound, via
+// WrapTChanServers) and outbound calls (Wrap) with circuit breaking,
+// rate limiting and bounded retry; common.NewService does not create or
+// apply one. Settings are per-method, loaded from YAML and hot-reloaded
+// through dconfigclient; breaker and limiter state is created lazily per
+// method on first use and rebuilt whenever a reload changes that
+// method's settings.
+type Manager struct {
+	serviceName string
+	reporter    common.LoadReporter
+	provider    *Provider
+
+	mu            sync.Mutex
+	breakers      map[string]*CircuitBreaker
+	breakerConfig map[string]CircuitBreakerSettings
+	limiters      map[string]*RateLimiter
+	limiterConfig map[string][2]int // [qps, burst]
+}
+
+// NewManager starts hot-reloading serviceName's governance config
+// through dClient and returns a Manager ready to wrap calls.
+func NewManager(serviceName string, dClient dconfigclient.Client, reporter common.LoadReporter) *Manager {
+	m := &Manager{
+		serviceName:   serviceName,
+		reporter:      reporter,
+		breakers:      make(map[string]*CircuitBreaker),
+		breakerConfig: make(map[string]CircuitBreakerSettings),
+		limiters:      make(map[string]*RateLimiter),
+		limiterConfig: make(map[string][2]int),
+	}
+	m.provider = NewProvider(dClient, serviceName, configRefreshInterval)
+	return m
+}
+
+// Stop halts the Manager's background config refresh.
+func (m *Manager) Stop() {
+	m.provider.Stop()
+}
+
+// Wrap returns call wrapped with whatever governance concerns method
+// currently has configured; a method with no configured settings is
+// returned unwrapped. rateLimitKey, if non-empty, is appended to method
+// to key the rate limiter more finely (e.g. by consumer group) than
+// per-method alone.
+func (m *Manager) Wrap(method, rateLimitKey string, call Call) Call {
+	settings, ok := m.provider.Settings()[method]
+	if !ok {
+		return call
+	}
+
+	var middlewares []Middleware
+
+	if settings.CircuitBreaker.FailureRatio > 0 || settings.CircuitBreaker.WindowSize > 0 {
+		middlewares = append(middlewares, CircuitBreakerMiddleware(m.breakerFor(method, settings.CircuitBreaker)))
+	}
+	if settings.RateLimitQPS > 0 {
+		limiter := m.limiterFor(method, settings.RateLimitQPS, settings.RateLimitBurst)
+		reporter := m.reporter
+		limitKey := method
+		if rateLimitKey != "" {
+			limitKey += ":" + rateLimitKey
+		}
+		middlewares = append(middlewares, func(next Call) Call {
+			wrapped := RateLimiterMiddleware(limiter, func(context.Context) string {
+				return limitKey
+			})(next)
+			return func(ctx context.Context) (interface{}, error) {
+				result, err := wrapped(ctx)
+				if err == ErrRateLimited {
+					reporter.IncCounter(metricRateLimited, map[string]string{"method": method}, 1)
+				}
+				return result, err
+			}
+		})
+	}
+	if settings.Retry.MaxAttempts > 1 {
+		reporter := m.reporter
+		middlewares = append(middlewares, func(next Call) Call {
+			counted := func(ctx context.Context) (interface{}, error) {
+				reporter.IncCounter(metricRetryAttempt, map[string]string{"method": method}, 1)
+				return next(ctx)
+			}
+			return RetryMiddleware(settings.Retry)(counted)
+		})
+	}
+
+	if len(middlewares) == 0 {
+		return call
+	}
+	return Chain(middlewares...)(call)
+}
+
+// WrapInbound returns call wrapped with method's circuit breaker and
+// rate limiter only — never retry, since retrying an inbound RPC means
+// re-running a handler against a request that has already been
+// delivered once (and, for TChannel, whose wire-level request has
+// already been consumed). Outbound calls should use Wrap instead, which
+// includes retry.
+func (m *Manager) WrapInbound(method string, call Call) Call {
+	settings, ok := m.provider.Settings()[method]
+	if !ok {
+		return call
+	}
+
+	var middlewares []Middleware
+
+	if settings.CircuitBreaker.FailureRatio > 0 || settings.CircuitBreaker.WindowSize > 0 {
+		middlewares = append(middlewares, CircuitBreakerMiddleware(m.breakerFor(method, settings.CircuitBreaker)))
+	}
+	if settings.RateLimitQPS > 0 {
+		limiter := m.limiterFor(method, settings.RateLimitQPS, settings.RateLimitBurst)
+		reporter := m.reporter
+		middlewares = append(middlewares, func(next Call) Call {
+			wrapped := RateLimiterMiddleware(limiter, func(context.Context) string { return method })(next)
+			return func(ctx context.Context) (interface{}, error) {
+				result, err := wrapped(ctx)
+				if err == ErrRateLimited {
+					reporter.IncCounter(metricRateLimited, map[string]string{"method": method}, 1)
+				}
+				return result, err
+			}
+		})
+	}
+
+	if len(middlewares) == 0 {
+		return call
+	}
+	return Chain(middlewares...)(call)
+}
+
+// breakerFor returns the CircuitBreaker for method, recreating it
+// (losing its current trip state) whenever a config reload has changed
+// settings, so a hot-reloaded threshold takes effect without a restart.
+func (m *Manager) breakerFor(method string, settings CircuitBreakerSettings) *CircuitBreaker {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if b, ok := m.breakers[method]; ok && breakerSettingsEqual(m.breakerConfig[method], settings) {
+		return b
+	}
+
+	applied := settings
+	applied.Name = m.serviceName + "." + method
+	reporter := m.reporter
+	applied.OnStateChange = func(name string, from, to State) {
+		if to == Open {
+			reporter.IncCounter(metricBreakerTrip, map[string]string{"method": name}, 1)
+		} else if from == Open {
+			reporter.IncCounter(metricBreakerReset, map[string]string{"method": name}, 1)
+		}
+	}
+	b := NewCircuitBreaker(applied)
+	m.breakers[method] = b
+	m.breakerConfig[method] = settings
+	return b
+}
+
+// breakerSettingsEqual compares the user-configurable fields of two
+// CircuitBreakerSettings, ignoring Name/OnStateChange (which breakerFor
+// fills in itself and which aren't comparable with ==).
+func breakerSettingsEqual(a, b CircuitBreakerSettings) bool {
+	return a.WindowSize == b.WindowSize &&
+		a.BucketCount == b.BucketCount &&
+		a.MinRequests == b.MinRequests &&
+		a.FailureRatio == b.FailureRatio &&
+		a.OpenDuration == b.OpenDuration
+}
+
+// limiterFor returns the RateLimiter for method, recreating it whenever
+// a config reload has changed qps/burst, so a hot-reloaded limit takes
+// effect without a restart.
+func (m *Manager) limiterFor(method string, qps, burst int) *RateLimiter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if burst <= 0 {
+		burst = qps
+	}
+	key := [2]int{qps, burst}
+	if l, ok := m.limiters[method]; ok && m.limiterConfig[method] == key {
+		return l
+	}
+
+	l := NewRateLimiter(qps, burst)
+	m.limiters[method] = l
+	m.limiterConfig[method] = key
+	return l
+}
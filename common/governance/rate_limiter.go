@@ -0,0 +1,66 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package governance
+
+import (
+	"errors"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrRateLimited is returned when a key has exhausted its token-bucket
+// budget for the current instant.
+var ErrRateLimited = errors.New("governance: rate limit exceeded")
+
+// RateLimiter hands out a token-bucket limiter per key (typically
+// "method" or "method:consumerGroup"), so one hot consumer group can be
+// throttled without affecting the rest of a method's traffic.
+type RateLimiter struct {
+	qps   int
+	burst int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// NewRateLimiter returns a RateLimiter that allows qps requests/sec,
+// bursting up to burst, per key.
+func NewRateLimiter(qps, burst int) *RateLimiter {
+	return &RateLimiter{qps: qps, burst: burst, limiters: make(map[string]*rate.Limiter)}
+}
+
+// Allow reports whether a request for key may proceed right now.
+func (r *RateLimiter) Allow(key string) bool {
+	return r.limiterFor(key).Allow()
+}
+
+func (r *RateLimiter) limiterFor(key string) *rate.Limiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.limiters[key]
+	if !ok {
+		l = rate.NewLimiter(rate.Limit(r.qps), r.burst)
+		r.limiters[key] = l
+	}
+	return l
+}
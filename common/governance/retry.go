@@ -0,0 +1,78 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package governance
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// RetrySettings bounds a retry loop with exponential backoff and jitter.
+type RetrySettings struct {
+	// MaxAttempts is the total number of tries, including the first
+	// (non-retry) attempt. A value <= 1 means "no retries".
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry; it doubles on
+	// every subsequent attempt up to MaxDelay.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff between attempts.
+	MaxDelay time.Duration
+}
+
+// Retry calls fn until it succeeds, ctx is done, or MaxAttempts is
+// reached, backing off exponentially with full jitter between attempts.
+// It returns the last error seen.
+func Retry(ctx context.Context, settings RetrySettings, fn func() error) error {
+	if settings.MaxAttempts <= 0 {
+		settings.MaxAttempts = 1
+	}
+
+	var err error
+	delay := settings.BaseDelay
+
+	for attempt := 0; attempt < settings.MaxAttempts; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+
+		if attempt == settings.MaxAttempts-1 {
+			break
+		}
+
+		if delay <= 0 {
+			delay = 50 * time.Millisecond
+		}
+		jittered := time.Duration(rand.Int63n(int64(delay)))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(jittered):
+		}
+
+		delay *= 2
+		if settings.MaxDelay > 0 && delay > settings.MaxDelay {
+			delay = settings.MaxDelay
+		}
+	}
+	return err
+}
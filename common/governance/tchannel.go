@@ -0,0 +1,97 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package governance
+
+import (
+	"context"
+	"errors"
+
+	athrift "github.com/apache/thrift/lib/go/thrift"
+	"github.com/uber/tchannel-go/thrift"
+)
+
+// tchanCall bundles a Handle invocation's return values so they can
+// travel through a governance Call's single (interface{}, error) shape.
+type tchanCall struct {
+	handled bool
+	resp    athrift.TStruct
+}
+
+// wrappedTChanServer decorates a thrift.TChanServer's Handle method with
+// a Manager's circuit breaker and rate limiter (never retry — see
+// Manager.WrapInbound), so a slow or failing downstream dependency can't
+// let one service's inbound RPCs pile up against the same handler.
+type wrappedTChanServer struct {
+	thrift.TChanServer
+	manager *Manager
+}
+
+// WrapTChanServer returns a thrift.TChanServer whose Handle dispatches
+// through manager.WrapInbound for every method, using "<Service>.<Method>"
+// as the governance config key so breaker/rate-limit settings can be
+// tuned per RPC the same way Manager.Wrap's outbound callers are.
+func WrapTChanServer(manager *Manager, tc thrift.TChanServer) thrift.TChanServer {
+	return &wrappedTChanServer{TChanServer: tc, manager: manager}
+}
+
+// WrapTChanServers applies WrapTChanServer to every server in tcs, the
+// shape every Start*Service entrypoint hands to h.Start(tc).
+func WrapTChanServers(manager *Manager, tcs []thrift.TChanServer) []thrift.TChanServer {
+	wrapped := make([]thrift.TChanServer, len(tcs))
+	for i, tc := range tcs {
+		wrapped[i] = WrapTChanServer(manager, tc)
+	}
+	return wrapped
+}
+
+func (w *wrappedTChanServer) Handle(ctx thrift.Context, methodName string, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
+	method := w.TChanServer.Service() + "." + methodName
+
+	call := w.manager.WrapInbound(method, func(context.Context) (interface{}, error) {
+		handled, resp, err := w.TChanServer.Handle(ctx, methodName, protocol)
+		if err != nil {
+			return tchanCall{handled: handled, resp: resp}, err
+		}
+		return tchanCall{handled: handled, resp: resp}, nil
+	})
+
+	result, err := call(ctx)
+	if err != nil {
+		if result == nil {
+			// The breaker/limiter rejected the call before
+			// w.TChanServer.Handle ever ran, so there's no tchanCall to
+			// unpack. methodName is still a real, known method on this
+			// service — it's just being refused right now — so handled
+			// must be true; false would tell the tchannel dispatcher the
+			// method doesn't exist, turning a retryable throttle/trip
+			// into an unknown-method error.
+			return true, nil, err
+		}
+		r := result.(tchanCall)
+		return r.handled, r.resp, err
+	}
+
+	r, ok := result.(tchanCall)
+	if !ok {
+		return false, nil, errors.New("governance: unexpected Handle result type")
+	}
+	return r.handled, r.resp, nil
+}
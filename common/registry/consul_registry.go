@@ -0,0 +1,131 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package registry
+
+import (
+	"fmt"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+
+	"github.com/uber/cherami-server/common/configure"
+)
+
+func init() {
+	RegisterDriver("consul", newConsulRegistry)
+}
+
+type consulRegistry struct {
+	client *consulapi.Client
+}
+
+func newConsulRegistry(cfg *configure.ServiceConfig) (Registry, error) {
+	hosts := cfg.GetRegistryHosts()
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("registry: consul driver requires at least one agent address")
+	}
+
+	client, err := consulapi.NewClient(&consulapi.Config{Address: hosts[0]})
+	if err != nil {
+		return nil, err
+	}
+	return &consulRegistry{client: client}, nil
+}
+
+func checkID(serviceName, uuid string) string {
+	return "cherami-" + serviceName + "-" + uuid
+}
+
+func (r *consulRegistry) Register(serviceName, uuid, host string, port int, tags map[string]string, ttl time.Duration) error {
+	tagList := make([]string, 0, len(tags))
+	for k, v := range tags {
+		tagList = append(tagList, k+"="+v)
+	}
+
+	reg := &consulapi.AgentServiceRegistration{
+		ID:      uuid,
+		Name:    serviceName,
+		Address: host,
+		Port:    port,
+		Tags:    tagList,
+		Check: &consulapi.AgentServiceCheck{
+			CheckID:                        checkID(serviceName, uuid),
+			TTL:                            ttl.String(),
+			DeregisterCriticalServiceAfter: (ttl * 10).String(),
+		},
+	}
+	if err := r.client.Agent().ServiceRegister(reg); err != nil {
+		return err
+	}
+	return r.client.Agent().PassTTL(checkID(serviceName, uuid), "registered")
+}
+
+func (r *consulRegistry) Heartbeat(serviceName, uuid string) error {
+	return r.client.Agent().PassTTL(checkID(serviceName, uuid), "heartbeat")
+}
+
+func (r *consulRegistry) Deregister(serviceName, uuid string) error {
+	return r.client.Agent().ServiceDeregister(uuid)
+}
+
+func (r *consulRegistry) Watch(serviceName string) (<-chan []Instance, error) {
+	out := make(chan []Instance)
+
+	go func() {
+		defer close(out)
+
+		var lastIndex uint64
+		for {
+			entries, meta, err := r.client.Health().Service(serviceName, "", true, &consulapi.QueryOptions{
+				WaitIndex: lastIndex,
+				WaitTime:  5 * time.Minute,
+			})
+			if err != nil {
+				time.Sleep(time.Second)
+				continue
+			}
+			lastIndex = meta.LastIndex
+
+			instances := make([]Instance, 0, len(entries))
+			for _, e := range entries {
+				tags := make(map[string]string, len(e.Service.Tags))
+				for _, tag := range e.Service.Tags {
+					for i := 0; i < len(tag); i++ {
+						if tag[i] == '=' {
+							tags[tag[:i]] = tag[i+1:]
+							break
+						}
+					}
+				}
+				instances = append(instances, Instance{
+					ServiceName: serviceName,
+					UUID:        e.Service.ID,
+					Host:        e.Service.Address,
+					Port:        e.Service.Port,
+					Tags:        tags,
+				})
+			}
+			out <- instances
+		}
+	}()
+
+	return out, nil
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package registry
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/uber/cherami-server/common/configure"
+)
+
+// Factory builds a Registry from a service's config. Drivers register a
+// Factory under a name from an init() function, mirroring
+// clients/metadata.Driver.
+type Factory func(cfg *configure.ServiceConfig) (Registry, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// RegisterDriver makes a registry driver available under name. It
+// panics if factory is nil or RegisterDriver is called twice for the
+// same name.
+func RegisterDriver(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if factory == nil {
+		panic("registry: RegisterDriver called with nil factory")
+	}
+	if _, dup := factories[name]; dup {
+		panic("registry: RegisterDriver called twice for driver " + name)
+	}
+	factories[name] = factory
+}
+
+// New instantiates the registry driver named by cfg.GetRegistryDriver().
+// An empty/unset driver name means "no registry" -- ok is false in that
+// case so callers (common.RegisterWithDiscovery and
+// common.WatchWithDiscovery) can keep relying on ringpop membership
+// unchanged. common.NewService does not call New.
+func New(cfg *configure.ServiceConfig) (reg Registry, ok bool, err error) {
+	name := cfg.GetRegistryDriver()
+	if name == "" {
+		return nil, false, nil
+	}
+
+	factoriesMu.RLock()
+	factory, found := factories[name]
+	factoriesMu.RUnlock()
+	if !found {
+		return nil, false, fmt.Errorf("registry: unknown driver %q (forgot to import it?)", name)
+	}
+
+	reg, err = factory(cfg)
+	if err != nil {
+		return nil, false, err
+	}
+	return reg, true, nil
+}
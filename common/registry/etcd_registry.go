@@ -0,0 +1,131 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+
+	"github.com/uber/cherami-server/common/configure"
+)
+
+func init() {
+	RegisterDriver("etcd", newEtcdRegistry)
+}
+
+type etcdRegistry struct {
+	client *clientv3.Client
+	leases map[string]clientv3.LeaseID
+}
+
+func newEtcdRegistry(cfg *configure.ServiceConfig) (Registry, error) {
+	hosts := cfg.GetRegistryHosts()
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("registry: etcd driver requires at least one endpoint")
+	}
+
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   hosts,
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &etcdRegistry{client: client, leases: make(map[string]clientv3.LeaseID)}, nil
+}
+
+func instanceKey(serviceName, uuid string) string {
+	return "/cherami/registry/" + serviceName + "/" + uuid
+}
+
+func (r *etcdRegistry) Register(serviceName, uuid, host string, port int, tags map[string]string, ttl time.Duration) error {
+	ctx := context.Background()
+
+	lease, err := r.client.Grant(ctx, int64(ttl.Seconds()))
+	if err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(Instance{ServiceName: serviceName, UUID: uuid, Host: host, Port: port, Tags: tags})
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.client.Put(ctx, instanceKey(serviceName, uuid), string(value), clientv3.WithLease(lease.ID)); err != nil {
+		return err
+	}
+
+	r.leases[checkID(serviceName, uuid)] = lease.ID
+	return nil
+}
+
+func (r *etcdRegistry) Heartbeat(serviceName, uuid string) error {
+	lease, ok := r.leases[checkID(serviceName, uuid)]
+	if !ok {
+		return fmt.Errorf("registry: %s/%s was never registered with this client", serviceName, uuid)
+	}
+	_, err := r.client.KeepAliveOnce(context.Background(), lease)
+	return err
+}
+
+func (r *etcdRegistry) Deregister(serviceName, uuid string) error {
+	_, err := r.client.Delete(context.Background(), instanceKey(serviceName, uuid))
+	delete(r.leases, checkID(serviceName, uuid))
+	return err
+}
+
+func (r *etcdRegistry) Watch(serviceName string) (<-chan []Instance, error) {
+	prefix := "/cherami/registry/" + serviceName + "/"
+	out := make(chan []Instance)
+
+	emit := func(ctx context.Context) {
+		resp, err := r.client.Get(ctx, prefix, clientv3.WithPrefix())
+		if err != nil {
+			return
+		}
+		instances := make([]Instance, 0, len(resp.Kvs))
+		for _, kv := range resp.Kvs {
+			var inst Instance
+			if err := json.Unmarshal(kv.Value, &inst); err == nil {
+				instances = append(instances, inst)
+			}
+		}
+		out <- instances
+	}
+
+	go func() {
+		defer close(out)
+
+		ctx := context.Background()
+		emit(ctx)
+
+		watchChan := r.client.Watch(ctx, prefix, clientv3.WithPrefix())
+		for range watchChan {
+			emit(ctx)
+		}
+	}()
+
+	return out, nil
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package registry abstracts service discovery/registration so that a
+// Cherami deployment isn't limited to the internal ring/Hyperbahn for
+// peer discovery. A Registry is selected by name (see driver.go) from
+// configure.ServiceConfig, the same way clients/metadata selects a
+// metadata.Driver.
+package registry
+
+import "time"
+
+// Instance is a single running instance of a service as reported by a
+// Registry backend.
+type Instance struct {
+	ServiceName string
+	UUID        string
+	Host        string
+	Port        int
+	Tags        map[string]string
+}
+
+// Registry abstracts service discovery/registration behind Consul- and
+// etcd-shaped semantics: a Register/Deregister pair with a health TTL
+// that must be refreshed on a ticker, and a Watch stream driven by
+// long-poll/blocking queries (Consul) or a native watch (etcd) rather
+// than ringpop membership.
+type Registry interface {
+	// Register advertises an instance under serviceName with the given
+	// health TTL; the caller is responsible for calling Heartbeat
+	// before the TTL expires, or the backend will consider the
+	// instance unhealthy and stop returning it from Watch.
+	Register(serviceName, uuid, host string, port int, tags map[string]string, ttl time.Duration) error
+
+	// Heartbeat refreshes the health TTL for a previously registered instance.
+	Heartbeat(serviceName, uuid string) error
+
+	// Deregister removes an instance, e.g. on clean shutdown.
+	Deregister(serviceName, uuid string) error
+
+	// Watch returns a channel that receives the current healthy
+	// instance set for serviceName every time membership changes. The
+	// channel is closed if the watch cannot be re-established.
+	Watch(serviceName string) (<-chan []Instance, error)
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package common
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/uber/cherami-server/common/configure"
+	"github.com/uber/cherami-server/common/registry"
+)
+
+// registryHeartbeatTTL is the health TTL a service's registration is
+// advertised with; Heartbeat is called at a third of it so a single
+// missed tick never flaps an otherwise-healthy instance out of Watch.
+const registryHeartbeatTTL = 15 * time.Second
+
+// RegisterWithDiscovery registers this service instance with the
+// service-discovery registry configured in cfg (see
+// configure.ServiceConfig.GetRegistryDriver), if any, and starts a
+// background heartbeat loop for as long as the process runs. It is a
+// no-op, returning (false, nil), when no registry driver is configured,
+// so existing deployments keep relying on ringpop/Hyperbahn membership
+// unchanged.
+//
+// Call this right after NewService, passing the same uuid/host/port it
+// was built with, e.g.:
+//
+//	sCommon := common.NewService(serviceName, hostUUID, cfg.GetServiceConfig(serviceName), ...)
+//	common.RegisterWithDiscovery(cfg.GetServiceConfig(serviceName), serviceName, hostUUID, host, port, nil)
+func RegisterWithDiscovery(cfg *configure.ServiceConfig, serviceName, hostUUID, host string, port int, tags map[string]string) (bool, error) {
+	reg, ok, err := registry.New(cfg)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if err := reg.Register(serviceName, hostUUID, host, port, tags, registryHeartbeatTTL); err != nil {
+		return false, err
+	}
+
+	go func() {
+		ticker := time.NewTicker(registryHeartbeatTTL / 3)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := reg.Heartbeat(serviceName, hostUUID); err != nil {
+				log.WithField(TagErr, err).Warn("common: registry heartbeat failed")
+			}
+		}
+	}()
+
+	return true, nil
+}
+
+// PlacementSource exposes the most recently observed healthy instance
+// set for a watched service, fed in the background from a registry
+// Watch stream, so placement logic can read the current membership
+// without blocking on the channel itself.
+type PlacementSource struct {
+	mu        sync.RWMutex
+	instances []registry.Instance
+}
+
+// Instances returns the most recent instance set Watch has delivered
+// (nil until the first update arrives).
+func (p *PlacementSource) Instances() []registry.Instance {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.instances
+}
+
+func (p *PlacementSource) set(instances []registry.Instance) {
+	p.mu.Lock()
+	p.instances = instances
+	p.mu.Unlock()
+}
+
+// WatchWithDiscovery is the extent-placement counterpart to
+// RegisterWithDiscovery: it starts watching serviceName's membership
+// through the registry driver configured in cfg, if any, and returns a
+// PlacementSource that's kept current in the background. ok is false,
+// same as RegisterWithDiscovery, when no registry driver is configured
+// — callers should fall back to ringpop membership for placement in
+// that case, unchanged.
+func WatchWithDiscovery(cfg *configure.ServiceConfig, serviceName string) (source *PlacementSource, ok bool, err error) {
+	reg, ok, err := registry.New(cfg)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	ch, err := reg.Watch(serviceName)
+	if err != nil {
+		return nil, false, err
+	}
+
+	source = &PlacementSource{}
+	go func() {
+		for instances := range ch {
+			source.set(instances)
+		}
+	}()
+
+	return source, true, nil
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package gatewayhost
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	urlpkg "net/url"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// ErrUnauthenticated is returned by an AuthHandler when the request
+// carries no usable credentials, or carries credentials that don't
+// validate.
+var ErrUnauthenticated = errors.New("gatewayhost: unauthenticated request")
+
+// AuthHandler authenticates an inbound REST/gRPC request and returns the
+// identity to attribute the call to. Unlike the TChannel-facing
+// services, which trust common.AuthManager (bypassed by default since
+// they only ever see traffic from other Cherami hosts), the gateway
+// terminates untrusted, internet-facing connections and so always runs
+// a real AuthHandler in front of publish/consume.
+type AuthHandler interface {
+	Authenticate(r *http.Request) (identity string, err error)
+}
+
+// bearerToken extracts the token from a "Bearer <token>" Authorization
+// header, the convention both the JWT and OAuth2 handlers below expect.
+func bearerToken(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(header, prefix) {
+		return "", ErrUnauthenticated
+	}
+	return strings.TrimPrefix(header, prefix), nil
+}
+
+// JWTAuthHandler validates a bearer JWT against a fixed signing key,
+// extracting the identity from its "sub" claim.
+type JWTAuthHandler struct {
+	signingKey []byte
+}
+
+// NewJWTAuthHandler returns an AuthHandler that validates HS256-signed
+// bearer JWTs using signingKey.
+func NewJWTAuthHandler(signingKey []byte) *JWTAuthHandler {
+	return &JWTAuthHandler{signingKey: signingKey}
+}
+
+func (h *JWTAuthHandler) Authenticate(r *http.Request) (string, error) {
+	raw, err := bearerToken(r)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := jwt.Parse(raw, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("gatewayhost: unexpected JWT signing method")
+		}
+		return h.signingKey, nil
+	})
+	if err != nil || !token.Valid {
+		return "", ErrUnauthenticated
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", ErrUnauthenticated
+	}
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return "", ErrUnauthenticated
+	}
+	return sub, nil
+}
+
+// TokenIntrospector calls out to an OAuth2 authorization server's token
+// introspection endpoint (RFC 7662) to validate a bearer token.
+type TokenIntrospector interface {
+	Introspect(token string) (active bool, identity string, err error)
+}
+
+// OAuth2AuthHandler validates a bearer token via token introspection.
+type OAuth2AuthHandler struct {
+	introspector TokenIntrospector
+}
+
+// NewOAuth2AuthHandler returns an AuthHandler that delegates bearer
+// token validation to introspector.
+func NewOAuth2AuthHandler(introspector TokenIntrospector) *OAuth2AuthHandler {
+	return &OAuth2AuthHandler{introspector: introspector}
+}
+
+func (h *OAuth2AuthHandler) Authenticate(r *http.Request) (string, error) {
+	raw, err := bearerToken(r)
+	if err != nil {
+		return "", err
+	}
+
+	active, identity, err := h.introspector.Introspect(raw)
+	if err != nil {
+		return "", err
+	}
+	if !active {
+		return "", ErrUnauthenticated
+	}
+	return identity, nil
+}
+
+// httpIntrospector is a TokenIntrospector that POSTs to an RFC 7662
+// token introspection endpoint.
+type httpIntrospector struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewHTTPIntrospector returns a TokenIntrospector backed by the RFC 7662
+// introspection endpoint at url.
+func NewHTTPIntrospector(url string) TokenIntrospector {
+	return &httpIntrospector{url: url, httpClient: &http.Client{Timeout: 5 * time.Second}}
+}
+
+func (h *httpIntrospector) Introspect(token string) (bool, string, error) {
+	resp, err := h.httpClient.PostForm(h.url, urlpkg.Values{"token": {token}})
+	if err != nil {
+		return false, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, "", fmt.Errorf("gatewayhost: introspection endpoint returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Active   bool   `json:"active"`
+		Username string `json:"username"`
+		Subject  string `json:"sub"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return false, "", err
+	}
+	if !body.Active {
+		return false, "", nil
+	}
+
+	identity := body.Subject
+	if identity == "" {
+		identity = body.Username
+	}
+	return true, identity, nil
+}
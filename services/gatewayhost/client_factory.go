@@ -0,0 +1,120 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package gatewayhost
+
+import (
+	"context"
+
+	"github.com/uber/cherami-client-go/client/cherami"
+
+	"github.com/uber/cherami-server/common"
+	"github.com/uber/cherami-server/common/configure"
+)
+
+// FrontendClient is the subset of the native Cherami client the gateway
+// needs to translate REST/gRPC calls into publish/consume requests
+// against frontendhost (which fans out to inputhost/outputhost in turn),
+// so that non-TChannel clients never have to link the native client
+// themselves.
+type FrontendClient interface {
+	// Publish writes data to the destination at path, returning the
+	// receipt the producer can use to correlate acks.
+	Publish(ctx context.Context, path string, data []byte) (receipt string, err error)
+
+	// Consume reads up to maxMessages from the consumer group at path
+	// without blocking past what is already buffered.
+	Consume(ctx context.Context, path string, maxMessages int) ([][]byte, error)
+}
+
+// ClientFactory builds the FrontendClient the gateway talks to. Mirrors
+// replicator.NewReplicatorClientFactory: one factory per process, reused
+// across requests rather than dialing per-call.
+type ClientFactory interface {
+	GetClient() (FrontendClient, error)
+}
+
+type cheramiClientFactory struct {
+	cfg *configure.CommonConfigure
+}
+
+// NewClientFactory returns a ClientFactory that dials frontendhost via
+// the native Cherami Go client, using the frontend address from cfg.
+func NewClientFactory(cfg *configure.CommonConfigure) ClientFactory {
+	return &cheramiClientFactory{cfg: cfg}
+}
+
+func (f *cheramiClientFactory) GetClient() (FrontendClient, error) {
+	frontend := f.cfg.GetServiceConfig(common.FrontendServiceName)
+	client, err := cherami.NewClient("cherami-gateway", frontend.GetListenAddress().String(), frontend.GetPort(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &cheramiClientAdapter{client: client}, nil
+}
+
+// cheramiClientAdapter adapts the native cherami.Client to the narrower
+// FrontendClient interface the gateway's HTTP/gRPC handlers use.
+type cheramiClientAdapter struct {
+	client cherami.Client
+}
+
+func (a *cheramiClientAdapter) Publish(ctx context.Context, path string, data []byte) (string, error) {
+	publisher, err := a.client.CreatePublisher(&cherami.CreatePublisherRequest{Path: path})
+	if err != nil {
+		return "", err
+	}
+	defer publisher.Close()
+
+	result := publisher.Publish(&cherami.PublisherMessage{Data: data})
+	if result.Error != nil {
+		return "", result.Error
+	}
+	return result.Receipt, nil
+}
+
+func (a *cheramiClientAdapter) Consume(ctx context.Context, path string, maxMessages int) ([][]byte, error) {
+	consumer, err := a.client.CreateConsumer(&cherami.CreateConsumerRequest{
+		Path:          path,
+		ConsumerName:  "cherami-gateway",
+		PrefetchCount: maxMessages,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer consumer.Close()
+
+	messages := make([][]byte, 0, maxMessages)
+	delivery := make(chan cherami.Delivery, maxMessages)
+	if _, err := consumer.Open(delivery); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < maxMessages; i++ {
+		select {
+		case d := <-delivery:
+			messages = append(messages, d.GetMessage().Payload.GetData())
+			d.Ack()
+		default:
+			return messages, nil
+		}
+	}
+	return messages, nil
+}
@@ -0,0 +1,231 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package gatewayhost implements the HTTP/gRPC ingress gateway, letting
+// clients that don't (or can't) link the native Cherami client publish
+// and consume over plain REST or gRPC instead.
+package gatewayhost
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/uber/cherami-server/common"
+	"github.com/uber/cherami-server/common/configure"
+	"github.com/uber/cherami-server/common/dconfigclient"
+	"github.com/uber/cherami-server/common/governance"
+	"github.com/uber/tchannel-go/thrift"
+)
+
+// GatewayHost serves the REST and gRPC ingress endpoints and translates
+// them into publish/consume calls against frontendhost via FrontendClient.
+type GatewayHost struct {
+	common.SCommon
+
+	serviceName string
+	client      FrontendClient
+	authHandler AuthHandler
+	dClient     dconfigclient.Client
+	governance  *governance.Manager
+
+	httpServer *http.Server
+}
+
+// NewGatewayHost creates the gateway host. clientFactory is called once,
+// here, to build the FrontendClient every request shares; if that fails
+// the process exits via log.Fatal.
+func NewGatewayHost(
+	serviceName string,
+	sCommon common.SCommon,
+	clientFactory ClientFactory,
+	authHandler AuthHandler,
+	cfg *configure.CommonConfigure,
+) (*GatewayHost, []thrift.TChanServer) {
+
+	client, err := clientFactory.GetClient()
+	if err != nil {
+		log.WithField(common.TagErr, err).Fatal("gatewayhost: unable to build frontend client")
+	}
+
+	dClient := sCommon.GetDConfigClient()
+	reporter := sCommon.GetLoadReportersFromName(serviceName)[0]
+
+	g := &GatewayHost{
+		SCommon:     sCommon,
+		serviceName: serviceName,
+		client:      client,
+		authHandler: authHandler,
+		dClient:     dClient,
+		governance:  governance.NewManager(serviceName, dClient, reporter),
+	}
+
+	// The gateway doesn't expose any TChannel-facing thrift services of
+	// its own; it only ever calls out to frontendhost.
+	return g, nil
+}
+
+// Start begins serving REST traffic. tc is unused (see NewGatewayHost)
+// but kept so the gateway fits the same Start(tc) shape as every other
+// Start*Service entrypoint.
+func (g *GatewayHost) Start(tc []thrift.TChanServer) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/destinations/", g.handlePublish)
+	mux.HandleFunc("/v1/consumer-groups/", g.handleConsume)
+
+	serviceCfg := g.GetServiceConfig(g.serviceName)
+	g.httpServer = &http.Server{
+		Addr:    net.JoinHostPort(serviceCfg.GetListenAddress().String(), strconv.Itoa(serviceCfg.GetPort())),
+		Handler: g.withAuth(mux),
+	}
+
+	go func() {
+		if err := g.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.WithField(common.TagErr, err).Error("gatewayhost: REST listener stopped")
+		}
+	}()
+
+	go g.startGRPC()
+}
+
+// withAuth runs every request through the configured AuthHandler before
+// handing it to next; requests that don't authenticate get a 401.
+func (g *GatewayHost) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := g.authHandler.Authenticate(r); err != nil {
+			http.Error(w, "unauthenticated", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (g *GatewayHost) handlePublish(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path, ok := pathParam(r.URL.Path, "/v1/destinations/", "/messages")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Read the body once, up front: the governance chain's retry
+	// middleware may invoke this Call more than once, and r.Body can only
+	// be drained a single time. Closing over the already-read bytes
+	// keeps every retry attempt publishing the same payload instead of
+	// an empty one.
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeGatewayError(w, errBadRequestBody{err})
+		return
+	}
+
+	call := g.governance.Wrap("Publish", path, func(ctx context.Context) (interface{}, error) {
+		return g.client.Publish(ctx, path, data)
+	})
+
+	result, err := call(r.Context())
+	if err != nil {
+		writeGatewayError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"receipt": result.(string)})
+}
+
+func (g *GatewayHost) handleConsume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path, ok := pathParam(r.URL.Path, "/v1/consumer-groups/", "/messages")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	call := g.governance.Wrap("Consume", path, func(ctx context.Context) (interface{}, error) {
+		return g.client.Consume(ctx, path, 100)
+	})
+
+	result, err := call(r.Context())
+	if err != nil {
+		writeGatewayError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"messages": result.([][]byte)})
+}
+
+// errBadRequestBody marks a request body that failed to read, so
+// writeGatewayError can tell it apart from a downstream call failure.
+type errBadRequestBody struct{ err error }
+
+func (e errBadRequestBody) Error() string { return e.err.Error() }
+
+// writeGatewayError maps a governance-wrapped call's error to the HTTP
+// status a client should see: 400 for a malformed request body, 429
+// when the per-route budget is exhausted or the breaker has tripped,
+// 503 for everything else.
+func writeGatewayError(w http.ResponseWriter, err error) {
+	switch {
+	case isBadRequestBody(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case err == governance.ErrRateLimited || err == governance.ErrCircuitOpen:
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	}
+}
+
+func isBadRequestBody(err error) bool {
+	_, ok := err.(errBadRequestBody)
+	return ok
+}
+
+// pathParam extracts the destination/consumer-group path out of a URL
+// like "/v1/destinations/shopping.orders/messages".
+func pathParam(urlPath, prefix, suffix string) (string, bool) {
+	if !strings.HasPrefix(urlPath, prefix) || !strings.HasSuffix(urlPath, suffix) {
+		return "", false
+	}
+	path := strings.TrimSuffix(strings.TrimPrefix(urlPath, prefix), suffix)
+	if path == "" {
+		return "", false
+	}
+	return path, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
@@ -0,0 +1,185 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package gatewayhost
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/uber/cherami-server/common"
+)
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// jsonCodec lets gRPC callers exchange plain JSON request/response
+// bodies instead of requiring a protobuf-generated client, the same
+// tradeoff the REST handlers already make. Callers select it with
+// grpc.CallContentSubtype("json") (or the server-side equivalent on
+// whatever gRPC client library they use).
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (jsonCodec) Name() string                               { return "json" }
+
+// PublishRequest/PublishResponse and ConsumeRequest/ConsumeResponse are
+// the gRPC-side counterparts of the REST handlers' request/response
+// bodies in gatewayhost.go.
+type PublishRequest struct {
+	Destination string `json:"destination"`
+	Data        []byte `json:"data"`
+}
+
+// PublishResponse carries the receipt handlePublish also returns.
+type PublishResponse struct {
+	Receipt string `json:"receipt"`
+}
+
+// ConsumeRequest names the consumer group to read from and caps how
+// many messages to return; MaxMessages <= 0 defaults the same way
+// handleConsume does.
+type ConsumeRequest struct {
+	ConsumerGroup string `json:"consumerGroup"`
+	MaxMessages   int32  `json:"maxMessages"`
+}
+
+// ConsumeResponse carries the messages handleConsume also returns.
+type ConsumeResponse struct {
+	Messages [][]byte `json:"messages"`
+}
+
+// Publish is the gRPC equivalent of handlePublish: same governance
+// wrapping, same FrontendClient call, just reached over gRPC instead of
+// REST.
+func (g *GatewayHost) Publish(ctx context.Context, req *PublishRequest) (*PublishResponse, error) {
+	call := g.governance.Wrap("Publish", req.Destination, func(ctx context.Context) (interface{}, error) {
+		return g.client.Publish(ctx, req.Destination, req.Data)
+	})
+
+	result, err := call(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &PublishResponse{Receipt: result.(string)}, nil
+}
+
+// Consume is the gRPC equivalent of handleConsume.
+func (g *GatewayHost) Consume(ctx context.Context, req *ConsumeRequest) (*ConsumeResponse, error) {
+	maxMessages := int(req.MaxMessages)
+	if maxMessages <= 0 {
+		maxMessages = 100
+	}
+
+	call := g.governance.Wrap("Consume", req.ConsumerGroup, func(ctx context.Context) (interface{}, error) {
+		return g.client.Consume(ctx, req.ConsumerGroup, maxMessages)
+	})
+
+	result, err := call(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &ConsumeResponse{Messages: result.([][]byte)}, nil
+}
+
+// gatewayServiceDesc registers Publish/Consume against grpc.Server by
+// hand rather than through protoc-generated code: the gateway has no
+// .proto contract of its own yet (it's a thin translation layer in
+// front of FrontendClient), so the handlers below decode requests
+// through jsonCodec instead of a generated unmarshaler.
+var gatewayServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cherami.gateway.GatewayService",
+	HandlerType: (*GatewayHost)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Publish",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := new(PublishRequest)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				if interceptor == nil {
+					return srv.(*GatewayHost).Publish(ctx, req)
+				}
+				info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/cherami.gateway.GatewayService/Publish"}
+				handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(*GatewayHost).Publish(ctx, req.(*PublishRequest))
+				}
+				return interceptor(ctx, req, info, handler)
+			},
+		},
+		{
+			MethodName: "Consume",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := new(ConsumeRequest)
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				if interceptor == nil {
+					return srv.(*GatewayHost).Consume(ctx, req)
+				}
+				info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/cherami.gateway.GatewayService/Consume"}
+				handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(*GatewayHost).Consume(ctx, req.(*ConsumeRequest))
+				}
+				return interceptor(ctx, req, info, handler)
+			},
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "gatewayhost/grpc.go",
+}
+
+// startGRPC brings up the gRPC listener on grpcPort(), one port above
+// the REST listener, and registers GatewayService so gRPC-only clients
+// can publish/consume the same way REST clients do through
+// handlePublish/handleConsume.
+func (g *GatewayHost) startGRPC() {
+	addr := g.GetServiceConfig(g.serviceName).GetListenAddress()
+	lis, err := net.Listen("tcp", net.JoinHostPort(addr.String(), g.grpcPort()))
+	if err != nil {
+		log.WithField(common.TagErr, err).Error("gatewayhost: unable to start gRPC listener")
+		return
+	}
+
+	server := grpc.NewServer()
+	server.RegisterService(&gatewayServiceDesc, g)
+
+	if err := server.Serve(lis); err != nil {
+		log.WithField(common.TagErr, err).Error("gatewayhost: gRPC listener stopped")
+	}
+}
+
+// grpcPort returns the gateway's gRPC port, one above its REST port so
+// the two can coexist on the same host the way websocket and thrift
+// ports already do for the other services.
+func (g *GatewayHost) grpcPort() string {
+	restPort := g.GetServiceConfig(g.serviceName).GetPort()
+	return strconv.Itoa(restPort + 1)
+}
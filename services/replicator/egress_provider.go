@@ -0,0 +1,102 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package replicator
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/uber/cherami-server/common"
+	"github.com/uber/cherami-server/common/dconfigclient"
+)
+
+// egressRuleConfigKey is the dconfigclient key the egress rule YAML
+// block is hot-reloaded from, the same way the gateway reads its
+// per-route rate limits through dClient.
+const egressRuleConfigKey = "replicator.egressRules"
+
+// EgressRuleProvider serves the current EgressRuleSet and keeps it fresh
+// by polling dconfigclient on a ticker, so operators can retune or add
+// egress rules without restarting the replicator.
+type EgressRuleProvider struct {
+	dClient dconfigclient.Client
+	current atomic.Value // holds *EgressRuleSet
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+// NewEgressRuleProvider starts polling dClient for the egress rule
+// config every refreshInterval and returns the provider once the first
+// load has completed (an empty rule set if the config key isn't set).
+func NewEgressRuleProvider(dClient dconfigclient.Client, refreshInterval time.Duration) *EgressRuleProvider {
+	p := &EgressRuleProvider{
+		dClient: dClient,
+		stopCh:  make(chan struct{}),
+	}
+	p.reload()
+
+	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.reload()
+			case <-p.stopCh:
+				return
+			}
+		}
+	}()
+
+	return p
+}
+
+func (p *EgressRuleProvider) reload() {
+	raw, ok := p.dClient.GetOrDefault(egressRuleConfigKey, "").(string)
+	if !ok || raw == "" {
+		p.current.Store(&EgressRuleSet{})
+		return
+	}
+
+	var rules EgressRuleSet
+	if err := yaml.Unmarshal([]byte(raw), &rules); err != nil {
+		log.WithField(common.TagErr, err).Error("replicator: failed to parse egress rule config, keeping previous rules")
+		return
+	}
+	p.current.Store(&rules)
+}
+
+// Rules returns the most recently loaded EgressRuleSet.
+func (p *EgressRuleProvider) Rules() *EgressRuleSet {
+	set, _ := p.current.Load().(*EgressRuleSet)
+	return set
+}
+
+// Stop halts the background refresh loop.
+func (p *EgressRuleProvider) Stop() {
+	p.stopOnce.Do(func() { close(p.stopCh) })
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package replicator
+
+import (
+	"time"
+
+	"github.com/uber/cherami-server/common"
+	"github.com/uber/cherami-server/common/dconfigclient"
+)
+
+// egressRuleRefreshInterval matches the gateway's governance config
+// refresh cadence; egress rules change about as often as rate limits
+// do, so there's no reason to poll dconfigclient any more often.
+const egressRuleRefreshInterval = 30 * time.Second
+
+// EgressRouter is the single entry point the replicator's outbound
+// extent-replication path calls to decide where (and whether) an extent
+// may replicate: it resolves the matching EgressRule via EgressRuleProvider
+// and enforces that rule's throttling via EgressThrottler, combining both
+// into the one decision a replication send loop needs per extent.
+type EgressRouter struct {
+	provider    *EgressRuleProvider
+	throttler   *EgressThrottler
+	staticZones []string
+}
+
+// NewEgressRouter starts hot-reloading egress rules through dClient and
+// returns a router that enforces them against staticRemoteZones, the
+// replicator's pre-existing fixed remote-zone list, which remains the
+// fallback for any destination no rule matches.
+func NewEgressRouter(dClient dconfigclient.Client, reporter common.LoadReporter, staticRemoteZones []string) *EgressRouter {
+	return &EgressRouter{
+		provider:    NewEgressRuleProvider(dClient, egressRuleRefreshInterval),
+		throttler:   NewEgressThrottler(reporter),
+		staticZones: staticRemoteZones,
+	}
+}
+
+// Route decides whether nBytes worth of outbound replication traffic for
+// an extent on destinationPath may proceed right now, and if so, which
+// zone(s) it should replicate to. allowed is false only when the
+// destination's matching egress rule is over its QPSCap or
+// BandwidthBytesPerSec budget; the caller should skip (not drop) the
+// extent and retry on its normal replication interval.
+func (r *EgressRouter) Route(destinationPath string, nBytes int) (zones []string, allowed bool) {
+	rules := r.provider.Rules()
+	zones = rules.ResolveTargetZones(destinationPath, r.staticZones)
+
+	if rule := rules.Match(destinationPath); rule != nil {
+		allowed = r.throttler.Allow(rule, nBytes)
+	} else {
+		allowed = true
+	}
+	return zones, allowed
+}
+
+// Stop halts the router's background rule refresh.
+func (r *EgressRouter) Stop() {
+	r.provider.Stop()
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package replicator
+
+import "path"
+
+// EgressRule names a destination-path pattern and the cross-zone
+// routing policy applied to extents replicated out of destinations that
+// match it, e.g. "shopping.*" replicating to zone1 only while
+// "audit.*" replicates to zone1+zone2 with a bandwidth cap.
+type EgressRule struct {
+	// Name identifies the rule in metrics and logs.
+	Name string `yaml:"Name"`
+	// PathPattern is matched against the destination path using the
+	// same glob syntax as path.Match ('*' and '?'), e.g. "shopping.*".
+	PathPattern string `yaml:"PathPattern"`
+	// RemoteZones lists the zone(s) an extent matching this rule
+	// replicates to, overriding the replicator's static remote-zone list.
+	RemoteZones []string `yaml:"RemoteZones"`
+	// Port and Protocol, when set, override the default replicator
+	// inter-zone transport for this rule's traffic.
+	Port     int    `yaml:"Port"`
+	Protocol string `yaml:"Protocol"`
+	// BandwidthBytesPerSec caps this rule's aggregate outbound
+	// replication throughput; 0 means unlimited.
+	BandwidthBytesPerSec int64 `yaml:"BandwidthBytesPerSec"`
+	// QPSCap caps this rule's aggregate outbound replication request
+	// rate; 0 means unlimited.
+	QPSCap int `yaml:"QPSCap"`
+}
+
+// matches reports whether destinationPath falls under this rule's pattern.
+func (r *EgressRule) matches(destinationPath string) bool {
+	ok, err := path.Match(r.PathPattern, destinationPath)
+	return err == nil && ok
+}
+
+// EgressRuleSet is an ordered, first-match-wins collection of EgressRules.
+type EgressRuleSet struct {
+	Rules []*EgressRule `yaml:"Rules"`
+}
+
+// Match returns the first rule whose PathPattern matches destinationPath,
+// or nil if none do.
+func (s *EgressRuleSet) Match(destinationPath string) *EgressRule {
+	if s == nil {
+		return nil
+	}
+	for _, rule := range s.Rules {
+		if rule.matches(destinationPath) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// ResolveTargetZones returns the zone(s) an extent on destinationPath
+// should replicate to: the matching egress rule's RemoteZones if one
+// matches, otherwise staticRemoteZones unchanged. This is the drop-in
+// replacement for reading the replicator's static remote-zone list
+// directly when deciding where to replicate an outbound extent.
+func (s *EgressRuleSet) ResolveTargetZones(destinationPath string, staticRemoteZones []string) []string {
+	if rule := s.Match(destinationPath); rule != nil && len(rule.RemoteZones) > 0 {
+		return rule.RemoteZones
+	}
+	return staticRemoteZones
+}
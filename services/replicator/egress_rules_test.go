@@ -0,0 +1,51 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package replicator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEgressRuleSetMatchIsFirstMatchWins(t *testing.T) {
+	set := &EgressRuleSet{Rules: []*EgressRule{
+		{Name: "shopping", PathPattern: "shopping.*", RemoteZones: []string{"zone1"}},
+		{Name: "audit", PathPattern: "audit.*", RemoteZones: []string{"zone1", "zone2"}, BandwidthBytesPerSec: 50 << 20},
+		{Name: "catch-all", PathPattern: "*", RemoteZones: []string{"zone1"}},
+	}}
+
+	require.Equal(t, "shopping", set.Match("shopping.orders").Name)
+	require.Equal(t, "audit", set.Match("audit.logins").Name)
+	require.Equal(t, "catch-all", set.Match("unrelated.topic").Name)
+}
+
+func TestEgressRuleSetResolveTargetZonesFallsBackToStatic(t *testing.T) {
+	set := &EgressRuleSet{Rules: []*EgressRule{
+		{Name: "audit", PathPattern: "audit.*", RemoteZones: []string{"zone1", "zone2"}},
+	}}
+
+	require.Equal(t, []string{"zone1", "zone2"}, set.ResolveTargetZones("audit.logins", []string{"zone9"}))
+	require.Equal(t, []string{"zone9"}, set.ResolveTargetZones("shopping.orders", []string{"zone9"}))
+
+	var nilSet *EgressRuleSet
+	require.Equal(t, []string{"zone9"}, nilSet.ResolveTargetZones("anything", []string{"zone9"}))
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package replicator
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/uber/cherami-server/common"
+)
+
+// egressRuleMetrics are the per-rule counters surfaced through the
+// existing reporter, so operators can build alerts/dashboards on
+// selective cross-DC replication the same way they would for any other
+// replicator metric.
+const (
+	metricEgressRuleBytes     = "replicator.egress.rule.bytes"
+	metricEgressRuleRequests  = "replicator.egress.rule.requests"
+	metricEgressRuleThrottled = "replicator.egress.rule.throttled"
+)
+
+// EgressThrottler enforces each EgressRule's BandwidthBytesPerSec and
+// QPSCap using a pair of token buckets per rule, and emits per-rule
+// metrics via reporter.
+type EgressThrottler struct {
+	reporter common.LoadReporter
+
+	mu          sync.Mutex
+	qps         map[string]*rate.Limiter
+	qpsConfig   map[string]limiterConfig
+	bytes       map[string]*rate.Limiter
+	bytesConfig map[string]limiterConfig
+}
+
+// limiterConfig is the (limit, burst) a cached *rate.Limiter was built
+// with, so limiterFor can tell a hot-reloaded cap from an unchanged one.
+type limiterConfig struct {
+	limit rate.Limit
+	burst int
+}
+
+// NewEgressThrottler returns a throttler that reports through reporter.
+func NewEgressThrottler(reporter common.LoadReporter) *EgressThrottler {
+	return &EgressThrottler{
+		reporter:    reporter,
+		qps:         make(map[string]*rate.Limiter),
+		qpsConfig:   make(map[string]limiterConfig),
+		bytes:       make(map[string]*rate.Limiter),
+		bytesConfig: make(map[string]limiterConfig),
+	}
+}
+
+// limiterFor returns the cached limiter for ruleName, recreating it
+// (losing its current token balance) whenever limit or burst no longer
+// matches what it was built with, so a hot-reloaded cap takes effect
+// without a restart — the same pattern governance.Manager's
+// breakerFor/limiterFor already use.
+func (t *EgressThrottler) limiterFor(store map[string]*rate.Limiter, config map[string]limiterConfig, ruleName string, limit rate.Limit, burst int) *rate.Limiter {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cfg := limiterConfig{limit: limit, burst: burst}
+	if l, ok := store[ruleName]; ok && config[ruleName] == cfg {
+		return l
+	}
+
+	l := rate.NewLimiter(limit, burst)
+	store[ruleName] = l
+	config[ruleName] = cfg
+	return l
+}
+
+// Allow reports whether nBytes worth of outbound replication traffic for
+// rule may proceed right now, given its QPSCap and BandwidthBytesPerSec.
+// A rule with both caps set to 0 is always allowed. Every call is
+// counted; denied calls are additionally counted as throttled.
+func (t *EgressThrottler) Allow(rule *EgressRule, nBytes int) bool {
+	t.reporter.IncCounter(metricEgressRuleRequests, map[string]string{"rule": rule.Name}, 1)
+
+	allowed := true
+	if rule.QPSCap > 0 {
+		allowed = t.limiterFor(t.qps, t.qpsConfig, rule.Name, rate.Limit(rule.QPSCap), rule.QPSCap).Allow() && allowed
+	}
+	if rule.BandwidthBytesPerSec > 0 {
+		bw := int(rule.BandwidthBytesPerSec)
+		// Burst must cover the largest single extent this rule will ever
+		// be asked to admit, or rate.Limiter.AllowN rejects it outright
+		// (n > burst) and it can never replicate, no matter how long the
+		// caller waits. Grow the burst to fit nBytes instead of capping
+		// it at one second's worth of bandwidth. The burst only ever
+		// grows for an unchanged cap, so a smaller extent reuses the
+		// existing limiter (and its token balance) instead of rebuilding
+		// a full one.
+		burst := bw
+		if nBytes > burst {
+			burst = nBytes
+		}
+		t.mu.Lock()
+		if prev, ok := t.bytesConfig[rule.Name]; ok && prev.limit == rate.Limit(bw) && prev.burst > burst {
+			burst = prev.burst
+		}
+		t.mu.Unlock()
+		allowed = t.limiterFor(t.bytes, t.bytesConfig, rule.Name, rate.Limit(bw), burst).AllowN(time.Now(), nBytes) && allowed
+	}
+
+	if allowed {
+		t.reporter.IncCounter(metricEgressRuleBytes, map[string]string{"rule": rule.Name}, int64(nBytes))
+	} else {
+		t.reporter.IncCounter(metricEgressRuleThrottled, map[string]string{"rule": rule.Name}, 1)
+	}
+	return allowed
+}
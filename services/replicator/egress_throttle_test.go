@@ -0,0 +1,73 @@
+// Copyright (c) 2016 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package replicator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeLoadReporter is a no-op common.LoadReporter for tests that only
+// care about EgressThrottler's allow/deny decisions, not its metrics.
+type fakeLoadReporter struct{}
+
+func (fakeLoadReporter) IncCounter(name string, tags map[string]string, value int64) {}
+
+func TestEgressThrottlerAllowsUnthrottledRule(t *testing.T) {
+	throttler := NewEgressThrottler(fakeLoadReporter{})
+	rule := &EgressRule{Name: "unthrottled"}
+
+	require.True(t, throttler.Allow(rule, 1<<20))
+}
+
+func TestEgressThrottlerEnforcesQPSCap(t *testing.T) {
+	throttler := NewEgressThrottler(fakeLoadReporter{})
+	rule := &EgressRule{Name: "qps-capped", QPSCap: 1}
+
+	require.True(t, throttler.Allow(rule, 0))
+	require.False(t, throttler.Allow(rule, 0))
+}
+
+// An extent larger than one second's worth of a rule's
+// BandwidthBytesPerSec must still be allowed through eventually: the
+// burst must grow to fit it, rather than rejecting it forever because
+// rate.Limiter.AllowN(n) always fails when n exceeds the bucket's burst.
+func TestEgressThrottlerDoesNotPermanentlyStarveLargeExtents(t *testing.T) {
+	throttler := NewEgressThrottler(fakeLoadReporter{})
+	rule := &EgressRule{Name: "bandwidth-capped", BandwidthBytesPerSec: 1 << 20}
+
+	require.True(t, throttler.Allow(rule, 10<<20))
+	require.True(t, throttler.Allow(rule, 10<<20))
+}
+
+// A hot-reloaded QPSCap must take effect on the very next call, not just
+// for rules created after the reload.
+func TestEgressThrottlerLimiterForPicksUpChangedCap(t *testing.T) {
+	throttler := NewEgressThrottler(fakeLoadReporter{})
+	rule := &EgressRule{Name: "reloaded", QPSCap: 1}
+
+	require.True(t, throttler.Allow(rule, 0))
+	require.False(t, throttler.Allow(rule, 0))
+
+	rule.QPSCap = 100
+	require.True(t, throttler.Allow(rule, 0))
+}